@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/spf13/viper"
+)
+
+// calibrationConfig the calibration section of the config file, e.g.
+//
+//	calibration:
+//	  gamma: 2.8
+//	  red: 1.0
+//	  green: 0.85
+//	  blue: 0.7
+//	  maxBrightness: 160
+type calibrationConfig struct {
+	Disabled      bool    `mapstructure:"disabled"`
+	Gamma         float64 `mapstructure:"gamma"`
+	Red           float64 `mapstructure:"red"`
+	Green         float64 `mapstructure:"green"`
+	Blue          float64 `mapstructure:"blue"`
+	MaxBrightness uint8   `mapstructure:"maxBrightness"`
+}
+
+func init() {
+	viper.SetDefault("calibration.gamma", frame.DefaultGamma)
+	viper.SetDefault("calibration.red", 1.0)
+	viper.SetDefault("calibration.green", 1.0)
+	viper.SetDefault("calibration.blue", 1.0)
+	viper.SetDefault("calibration.maxBrightness", 255)
+}
+
+// loadCalibration builds the calibration frames are corrected with before they
+// go out; nil when it is disabled in the config
+func loadCalibration() (*frame.Calibration, error) {
+	config := calibrationConfig{}
+	if err := viper.UnmarshalKey("calibration", &config); err != nil {
+		return nil, err
+	}
+	if config.Disabled {
+		return nil, nil
+	}
+	return frame.NewCalibration(config.Gamma, config.Red, config.Green, config.Blue, config.MaxBrightness)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// canvas a drawing on the paint grid as saved to and opened from a file
+type canvas struct {
+	Rows, Columns uint16
+	Squares       []SquareInfo // the GridCord, Color and CreatedAt of every square drawn
+}
+
+// canvasDocument the JSON form of a canvas.  Each cell's age is how long before
+// the canvas was saved it was drawn, so fading and decay carry on where they
+// were when it is opened.
+type canvasDocument struct {
+	Rows    uint16       `json:"rows"`
+	Columns uint16       `json:"columns"`
+	SavedAt time.Time    `json:"savedAt"`
+	Cells   []canvasCell `json:"cells"`
+}
+
+type canvasCell struct {
+	Row       uint16 `json:"row"`
+	Column    uint16 `json:"column"`
+	Color     string `json:"color"`               // #rrggbbaa
+	AgeMillis *int64 `json:"ageMillis,omitempty"` // unset for squares never drawn on, e.g. from a PNG
+}
+
+// canvasFromGrid the squares of gridContents that aren't blank
+func canvasFromGrid(gridContents map[GridCord]SquareInfo, rows, columns uint16) canvas {
+	c := canvas{Rows: rows, Columns: columns}
+	for row := uint16(0); row < rows; row++ {
+		for column := uint16(0); column < columns; column++ {
+			if square, ok := gridContents[GridCord{Row: row, Column: column}]; ok && square.Color != rl.Blank {
+				c.Squares = append(c.Squares, square)
+			}
+		}
+	}
+	return c
+}
+
+// applyCanvas replaces the drawing in gridContents with c, leaving out squares
+// beyond the grid; it returns how many were left out
+func applyCanvas(gridContents map[GridCord]SquareInfo, c canvas) int {
+	for cord, square := range gridContents {
+		square.Color, square.CreatedAt = rl.Blank, time.Time{}
+		gridContents[cord] = square
+	}
+	dropped := 0
+	for _, opened := range c.Squares {
+		square, ok := gridContents[opened.GridCord]
+		if !ok {
+			dropped++
+			continue
+		}
+		square.Color, square.CreatedAt = opened.Color, opened.CreatedAt
+		gridContents[opened.GridCord] = square
+	}
+	return dropped
+}
+
+// saveCanvas writes c to path as a PNG of one pixel per square or as JSON, by
+// the extension of path
+func saveCanvas(path string, c canvas) error {
+	var write func(*os.File) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		write = func(file *os.File) error { return png.Encode(file, c.image()) }
+	case ".json":
+		write = func(file *os.File) error {
+			encoder := json.NewEncoder(file)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(c.document(time.Now()))
+		}
+	default:
+		return fmt.Errorf("can't save a canvas as %q; use .png or .json", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// openCanvas reads a canvas saved by saveCanvas, or any PNG whose pixels are squares
+func openCanvas(path string) (canvas, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return canvas{}, err
+	}
+	defer file.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		img, err := png.Decode(file)
+		if err != nil {
+			return canvas{}, fmt.Errorf("%s: %w", path, err)
+		}
+		return canvasFromImage(img)
+	case ".json":
+		doc := canvasDocument{}
+		if err := json.NewDecoder(file).Decode(&doc); err != nil {
+			return canvas{}, fmt.Errorf("%s: %w", path, err)
+		}
+		return doc.canvas(time.Now())
+	}
+	return canvas{}, fmt.Errorf("can't open a canvas from %q; use .png or .json", path)
+}
+
+func (c canvas) image() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, int(c.Columns), int(c.Rows)))
+	for _, square := range c.Squares {
+		img.SetNRGBA(int(square.GridCord.Column), int(square.GridCord.Row), color.NRGBA(square.Color))
+	}
+	return img
+}
+
+// canvasFromImage a canvas with a square for every pixel of img that isn't transparent
+func canvasFromImage(img image.Image) (canvas, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() > 0xFFFF || bounds.Dy() > 0xFFFF {
+		return canvas{}, fmt.Errorf("%dx%d image is too big for a canvas", bounds.Dx(), bounds.Dy())
+	}
+	c := canvas{Rows: uint16(bounds.Dy()), Columns: uint16(bounds.Dx())}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if pixel.A == 0 {
+				continue
+			}
+			c.Squares = append(c.Squares, SquareInfo{
+				GridCord: GridCord{Row: uint16(y - bounds.Min.Y), Column: uint16(x - bounds.Min.X)},
+				Color:    rl.Color(pixel),
+			})
+		}
+	}
+	return c, nil
+}
+
+func (c canvas) document(savedAt time.Time) canvasDocument {
+	doc := canvasDocument{Rows: c.Rows, Columns: c.Columns, SavedAt: savedAt, Cells: []canvasCell{}}
+	for _, square := range c.Squares {
+		cell := canvasCell{
+			Row:    square.GridCord.Row,
+			Column: square.GridCord.Column,
+			Color:  fmt.Sprintf("#%02x%02x%02x%02x", square.Color.R, square.Color.G, square.Color.B, square.Color.A),
+		}
+		if !square.CreatedAt.IsZero() {
+			age := int64(savedAt.Sub(square.CreatedAt) / time.Millisecond)
+			cell.AgeMillis = &age
+		}
+		doc.Cells = append(doc.Cells, cell)
+	}
+	return doc
+}
+
+// canvas the canvas of doc as if it were opened at now
+func (doc canvasDocument) canvas(now time.Time) (canvas, error) {
+	c := canvas{Rows: doc.Rows, Columns: doc.Columns}
+	for _, cell := range doc.Cells {
+		if cell.Row >= doc.Rows || cell.Column >= doc.Columns {
+			return canvas{}, fmt.Errorf("cell %d,%d is outside the %dx%d canvas", cell.Row, cell.Column, doc.Rows, doc.Columns)
+		}
+		square := SquareInfo{GridCord: GridCord{Row: cell.Row, Column: cell.Column}}
+		var r, g, b, a uint8
+		if n, err := fmt.Sscanf(cell.Color, "#%02x%02x%02x%02x", &r, &g, &b, &a); n != 4 || err != nil || len(cell.Color) != 9 {
+			return canvas{}, fmt.Errorf("cell %d,%d color %q isn't #rrggbbaa", cell.Row, cell.Column, cell.Color)
+		}
+		square.Color = rl.NewColor(r, g, b, a)
+		if cell.AgeMillis != nil {
+			square.CreatedAt = now.Add(-time.Duration(*cell.AgeMillis) * time.Millisecond)
+		}
+		c.Squares = append(c.Squares, square)
+	}
+	return c, nil
+}
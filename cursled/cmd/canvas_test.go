@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func testCanvas(drawnAt time.Time) (map[GridCord]SquareInfo, canvas) {
+	gridContents := makeGridContents(rl.NewVector2(0, 0), 3, 4)
+	for _, square := range []SquareInfo{
+		{GridCord: GridCord{Row: 0, Column: 0}, Color: rl.NewColor(255, 0, 0, 255), CreatedAt: drawnAt},
+		{GridCord: GridCord{Row: 2, Column: 3}, Color: rl.NewColor(10, 20, 30, 128), CreatedAt: drawnAt.Add(-time.Second)},
+		{GridCord: GridCord{Row: 1, Column: 2}, Color: rl.NewColor(0, 0, 0, 255)},
+	} {
+		s := gridContents[square.GridCord]
+		s.Color, s.CreatedAt = square.Color, square.CreatedAt
+		gridContents[square.GridCord] = s
+	}
+	return gridContents, canvasFromGrid(gridContents, 3, 4)
+}
+
+func TestCanvasRoundTrip(t *testing.T) {
+	drawnAt := time.Now().Add(-2 * time.Second)
+	want, saved := testCanvas(drawnAt)
+	if len(saved.Squares) != 3 {
+		t.Fatalf("canvas has %d squares, want the 3 drawn", len(saved.Squares))
+	}
+
+	for _, name := range []string{"canvas.png", "canvas.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := saveCanvas(path, saved); err != nil {
+			t.Fatal(err)
+		}
+		opened, err := openCanvas(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened.Rows != 3 || opened.Columns != 4 {
+			t.Errorf("%s: opened %dx%d, want 3x4", name, opened.Rows, opened.Columns)
+		}
+		got := makeGridContents(rl.NewVector2(0, 0), 3, 4)
+		if dropped := applyCanvas(got, opened); dropped != 0 {
+			t.Errorf("%s: %d squares dropped", name, dropped)
+		}
+		for cord, square := range want {
+			if got[cord].Color != square.Color {
+				t.Errorf("%s: %v is %v, want %v", name, cord, got[cord].Color, square.Color)
+			}
+			// only JSON keeps when squares were drawn, close enough for fading to carry on
+			if strings.HasSuffix(name, ".json") {
+				if diff := got[cord].CreatedAt.Sub(square.CreatedAt); diff < -time.Second || diff > time.Second || got[cord].CreatedAt.IsZero() != square.CreatedAt.IsZero() {
+					t.Errorf("%s: %v drawn at %v, want %v", name, cord, got[cord].CreatedAt, square.CreatedAt)
+				}
+			}
+		}
+	}
+}
+
+func TestCanvasDocument(t *testing.T) {
+	savedAt := time.Date(2020, 1, 1, 0, 0, 10, 0, time.UTC)
+	_, c := testCanvas(savedAt.Add(-1500 * time.Millisecond))
+	doc := c.document(savedAt)
+	if doc.Rows != 3 || doc.Columns != 4 || len(doc.Cells) != 3 {
+		t.Fatalf("document %+v", doc)
+	}
+	first := doc.Cells[0]
+	if first.Color != "#ff0000ff" || first.AgeMillis == nil || *first.AgeMillis != 1500 {
+		t.Errorf("first cell %+v", first)
+	}
+	if undrawn := doc.Cells[1]; undrawn.AgeMillis != nil {
+		t.Errorf("a square never drawn on has age %d", *undrawn.AgeMillis)
+	}
+
+	doc.Cells[0].Color = "red"
+	if _, err := doc.canvas(savedAt); err == nil {
+		t.Error("color that isn't #rrggbbaa accepted")
+	}
+	doc.Cells[0].Color, doc.Cells[0].Row = "#ff0000ff", 3
+	if _, err := doc.canvas(savedAt); err == nil {
+		t.Error("cell outside the canvas accepted")
+	}
+}
+
+func TestApplyCanvasCrops(t *testing.T) {
+	_, c := testCanvas(time.Now())
+	small := makeGridContents(rl.NewVector2(0, 0), 2, 2)
+	if dropped := applyCanvas(small, c); dropped != 2 {
+		t.Errorf("%d squares dropped, want 2", dropped)
+	}
+	if len(small) != 4 {
+		t.Errorf("grid grew to %d squares", len(small))
+	}
+	if err := saveCanvas(filepath.Join(t.TempDir(), "canvas.bmp"), c); err == nil {
+		t.Error("saved a canvas in an unknown format")
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aaronbush/go-stuff/cursled/colors"
+	rg "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pickerWidth    = 100 // of the color controls
+	swatchSize     = 11
+	swatchesPerRow = 8
+	recentColors   = swatchesPerRow
+)
+
+// errClamped a channel was typed beyond 0 to 255 and has been brought back into range
+var errClamped = errors.New("clamped to 0-255")
+
+// colorPicker the color paint draws with and what was typed to choose it.  The
+// hue and saturation are kept apart from the color so they stay put while the
+// color passes through greys and black.
+type colorPicker struct {
+	color   rl.Color
+	hsv     colors.HSV
+	text    [3]string // the red, green and blue inputs as typed
+	hex     string    // the hex input as typed
+	palette colors.Palette
+	recent  colors.Recent
+}
+
+func newColorPicker(c rl.Color, palette colors.Palette) *colorPicker {
+	p := &colorPicker{palette: palette, recent: colors.Recent{Limit: recentColors}}
+	p.set(c)
+	return p
+}
+
+// set makes c the color drawn with and shows it in every input that doesn't
+// already hold it
+func (p *colorPicker) set(c rl.Color) {
+	opaque := color.NRGBA{R: c.R, G: c.G, B: c.B, A: 0xFF}
+	if p.hsv.RGB() != opaque {
+		hsv := colors.FromRGB(opaque)
+		if hsv.V == 0 {
+			hsv.H, hsv.S = p.hsv.H, p.hsv.S
+		} else if hsv.S == 0 {
+			hsv.H = p.hsv.H
+		}
+		p.hsv = hsv
+	}
+	p.color = c
+
+	for i, channel := range []uint8{c.R, c.G, c.B} {
+		if v, err := parseChannel(p.text[i]); v != channel || (err != nil && err != errClamped) {
+			p.text[i] = strconv.Itoa(int(channel))
+		}
+	}
+	if typed, err := colors.ParseHex(p.hex); err != nil || typed != color.NRGBA(c) {
+		p.hex = colors.Hex(color.NRGBA(c))
+	}
+}
+
+// setHSV makes the color h, keeping its alpha
+func (p *colorPicker) setHSV(h colors.HSV) {
+	p.hsv = h
+	c := rl.Color(h.RGB())
+	c.A = p.color.A
+	p.set(c)
+}
+
+// used puts the color at the front of the recent colors, when something is drawn with it
+func (p *colorPicker) used() {
+	p.recent.Add(color.NRGBA(p.color))
+}
+
+// parseChannel reads a channel typed as a number from 0 to 255.  Numbers beyond
+// that are clamped and errClamped returned with them.
+func parseChannel(text string) (uint8, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(text))
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("%q is not a number", text)
+	case v < 0:
+		return 0, errClamped
+	case v > maxRGB:
+		return maxRGB, errClamped
+	}
+	return uint8(v), nil
+}
+
+// defaultPalette the palette used until one is loaded
+func defaultPalette() colors.Palette {
+	p := colors.Palette{Name: "cursled"}
+	for _, c := range []rl.Color{rl.White, rl.Gray, rl.Red, rl.Orange, rl.Yellow, rl.Green, rl.Blue, rl.Purple} {
+		p.Colors = append(p.Colors, colors.Swatch{Color: color.NRGBA(c)})
+	}
+	return p
+}
+
+// loadStartPalette the --palette palette, or the default one when it hasn't been saved yet
+func loadStartPalette() (colors.Palette, error) {
+	p, err := colors.Load(palettePath)
+	if os.IsNotExist(err) {
+		log.Info("new palette ", palettePath, " will be created when saved")
+		return defaultPalette(), nil
+	}
+	return p, err
+}
+
+// drawHSVPicker a square of saturation across and value down for the current
+// hue, over a bar of hues; dragging over either picks the color
+func drawHSVPicker(position rl.Vector2, p *colorPicker) rl.Vector2 {
+	square := rl.NewRectangle(position.X, position.Y, pickerWidth, pickerWidth)
+	hue := rl.Color(colors.HSV{H: p.hsv.H, S: 1, V: 1}.RGB())
+	rl.DrawRectangleGradientH(int32(square.X), int32(square.Y), pickerWidth, pickerWidth, rl.White, hue)
+	rl.DrawRectangleGradientV(int32(square.X), int32(square.Y), pickerWidth, pickerWidth, rl.Blank, rl.Black)
+	rl.DrawRectangleLines(int32(square.X+float32(p.hsv.S)*pickerWidth)-2, int32(square.Y+float32(1-p.hsv.V)*pickerWidth)-2, 5, 5, rl.Gray)
+
+	bar := rl.NewRectangle(position.X, position.Y+pickerWidth+5, pickerWidth, 10)
+	for i := 0; i < 6; i++ {
+		from, to := int32(bar.X)+int32(i)*pickerWidth/6, int32(bar.X)+int32(i+1)*pickerWidth/6
+		rl.DrawRectangleGradientH(from, int32(bar.Y), to-from, int32(bar.Height),
+			rl.Color(colors.HSV{H: float64(60 * i), S: 1, V: 1}.RGB()), rl.Color(colors.HSV{H: float64(60 * (i + 1)), S: 1, V: 1}.RGB()))
+	}
+	rl.DrawRectangleLines(int32(bar.X+float32(p.hsv.H/360)*pickerWidth)-1, int32(bar.Y)-1, 3, int32(bar.Height)+2, rl.Gray)
+
+	if rl.IsMouseButtonDown(rl.MouseLeftButton) {
+		mouse := rl.GetMousePosition()
+		if rl.CheckCollisionPointRec(mouse, square) {
+			p.setHSV(colors.HSV{H: p.hsv.H, S: float64((mouse.X - square.X) / pickerWidth), V: 1 - float64((mouse.Y-square.Y)/pickerWidth)})
+		} else if rl.CheckCollisionPointRec(mouse, bar) {
+			p.setHSV(colors.HSV{H: float64((mouse.X - bar.X) / pickerWidth * 360), S: p.hsv.S, V: p.hsv.V})
+		}
+	}
+	return rl.NewVector2(position.X, bar.Y+bar.Height+5)
+}
+
+// drawHexInput a box the color is typed into as hex, beside a swatch of it;
+// what can't be read is outlined and left until it is put right
+func drawHexInput(position rl.Vector2, p *colorPicker) rl.Vector2 {
+	box := rl.NewRectangle(position.X, position.Y, pickerWidth-25, 20)
+	if typed := rg.TextBox(box, p.hex); typed != p.hex {
+		p.hex = typed
+		if c, err := colors.ParseHex(typed); err == nil {
+			p.set(rl.Color(c))
+		}
+	}
+	if _, err := colors.ParseHex(p.hex); err != nil {
+		rl.DrawRectangleLinesEx(box, 1, rl.Red)
+	}
+	rl.DrawRectangleRec(rl.NewRectangle(position.X+pickerWidth-20, position.Y, 20, 20), p.color)
+	position.Y += 25
+	return position
+}
+
+// drawSwatches draws cs in rows from position; it returns the one under the
+// mouse, or -1, and where the rows end
+func drawSwatches(position rl.Vector2, cs []color.NRGBA) (int, rl.Vector2) {
+	hovered, mouse := -1, rl.GetMousePosition()
+	for i, c := range cs {
+		swatch := rl.NewRectangle(position.X+float32(i%swatchesPerRow*(swatchSize+1)), position.Y+float32(i/swatchesPerRow*(swatchSize+1)),
+			swatchSize, swatchSize)
+		rl.DrawRectangleRec(swatch, rl.Color(c))
+		if rl.CheckCollisionPointRec(mouse, swatch) {
+			hovered = i
+			rl.DrawRectangleLinesEx(swatch, 1, rl.Gray)
+		}
+	}
+	rows := (len(cs) + swatchesPerRow - 1) / swatchesPerRow
+	position.Y += float32(rows*(swatchSize+1)) + 4
+	return hovered, position
+}
+
+// drawPalette the recent colors and the palette, with buttons to add the color
+// to the palette and to save and load it; clicking a swatch picks its color and
+// right clicking one of the palette's removes it
+func drawPalette(position rl.Vector2, p *colorPicker) rl.Vector2 {
+	rg.Label(rl.NewRectangle(position.X, position.Y, pickerWidth, 20), "Recent")
+	position.Y += 20
+	hovered, position := drawSwatches(position, p.recent.Colors)
+	if hovered >= 0 && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+		p.set(rl.Color(p.recent.Colors[hovered]))
+	}
+
+	name := p.palette.Name
+	if name == "" {
+		name = "Palette"
+	}
+	rg.Label(rl.NewRectangle(position.X, position.Y, pickerWidth, 20), name)
+	position.Y += 20
+	swatches := make([]color.NRGBA, len(p.palette.Colors))
+	for i, s := range p.palette.Colors {
+		swatches[i] = s.Color
+	}
+	hovered, position = drawSwatches(position, swatches)
+	if hovered >= 0 && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+		p.set(rl.Color(swatches[hovered]))
+	}
+	if hovered >= 0 && rl.IsMouseButtonPressed(rl.MouseRightButton) {
+		p.palette.Colors = append(p.palette.Colors[:hovered], p.palette.Colors[hovered+1:]...)
+	}
+
+	if rg.Button(rl.NewRectangle(position.X, position.Y, 30, 20), "+") {
+		p.palette.Colors = append(p.palette.Colors, colors.Swatch{Color: color.NRGBA(p.color)})
+	}
+	if rg.Button(rl.NewRectangle(position.X+35, position.Y, 30, 20), "save") {
+		if err := colors.Save(palettePath, p.palette); err != nil {
+			log.Error("saving the palette: ", err)
+		} else {
+			log.Info("saved the palette to ", palettePath)
+		}
+	}
+	if rg.Button(rl.NewRectangle(position.X+70, position.Y, 30, 20), "load") {
+		if loaded, err := colors.Load(palettePath); err != nil {
+			log.Error("loading the palette: ", err)
+		} else {
+			p.palette = loaded
+		}
+	}
+	position.Y += 25
+	return position
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/aaronbush/go-stuff/cursled/colors"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		text string
+		want uint8
+		err  error
+	}{
+		{"128", 128, nil},
+		{" 7 ", 7, nil},
+		{"300", 255, errClamped},
+		{"-4", 0, errClamped},
+	}
+	for _, tt := range tests {
+		if got, err := parseChannel(tt.text); got != tt.want || err != tt.err {
+			t.Errorf("parseChannel(%q) = %d, %v, want %d, %v", tt.text, got, err, tt.want, tt.err)
+		}
+	}
+	for _, bad := range []string{"", "12a", "0x10"} {
+		if _, err := parseChannel(bad); err == nil || err == errClamped {
+			t.Errorf("parseChannel(%q) = %v, want it to be unreadable", bad, err)
+		}
+	}
+}
+
+func TestColorPicker(t *testing.T) {
+	p := newColorPicker(rl.NewColor(255, 0, 0, 255), defaultPalette())
+	if p.text != [3]string{"255", "0", "0"} || p.hex != "#ff0000" {
+		t.Fatalf("red shows as %v %s", p.text, p.hex)
+	}
+
+	p.setHSV(colors.HSV{H: 120, S: 1, V: 0})
+	if p.color != rl.NewColor(0, 0, 0, 255) || p.hsv.H != 120 {
+		t.Errorf("black keeps the hue it was picked with: %v %v", p.color, p.hsv)
+	}
+	p.set(rl.NewColor(128, 128, 128, 255))
+	if p.hsv.H != 120 || p.hsv.S != 0 {
+		t.Errorf("grey loses the hue picked: %v", p.hsv)
+	}
+
+	// what is typed stays as typed while it still means the color
+	p.text[0], p.hex = "300", "#zz"
+	p.set(rl.NewColor(255, 10, 20, 255))
+	if p.text != [3]string{"300", "10", "20"} || p.hex != "#ff0a14" {
+		t.Errorf("after setting shows %v %s", p.text, p.hex)
+	}
+
+	p.used()
+	p.set(rl.Blue)
+	p.used()
+	if len(p.recent.Colors) != 2 || rl.Color(p.recent.Colors[0]) != rl.Blue {
+		t.Errorf("recent colors %v", p.recent.Colors)
+	}
+}
@@ -1,58 +1,131 @@
 package main
 
 import (
-	"encoding/binary"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aaronbush/go-stuff/cursled/frame"
 	"github.com/gookit/color"
 )
 
-type ScreenKey struct {
-	Row    uint8
-	Column uint8
+// frameDecoder is satisfied by both a recording and a bare frame stream
+type frameDecoder interface {
+	Decode() (frame.Frame, error)
 }
 
 func main() {
-	file, err := os.Open("test.data")
+	frameNumber := flag.Int("frame", -1, "show the display at this frame of a recording")
+	at := flag.Duration("time", -1, "show the display at this time into a recording")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-frame N | -time T] file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flag.Arg(0))
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer file.Close()
 
-	ledInfo := frame.LEDInfo{}
-	ledInfos := make(map[ScreenKey]frame.LEDInfo)
+	recording, err := frame.NewReader(file)
+	if err == frame.ErrNotRecording {
+		if *frameNumber >= 0 || *at >= 0 {
+			log.Fatal("-frame and -time need a recording, not a bare frame stream")
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			log.Fatal(err)
+		}
+		drawTable(dumpFrames(frame.NewDecoder(file)), 0, 0)
+		return
+	} else if err != nil {
+		log.Fatal(err)
+	}
+
+	header := recording.Header()
+	log.Printf("%dx%d at %dfps, pixel format %d, %d frames\n", header.Rows, header.Columns, header.FPS, header.PixelFormat, recording.Len())
+	if recording.Len() == 0 {
+		return
+	}
+	if *at >= 0 {
+		*frameNumber = recording.Search(*at)
+	}
+	if *frameNumber >= 0 {
+		if *frameNumber >= recording.Len() {
+			log.Fatalf("no frame %d in a recording of %d", *frameNumber, recording.Len())
+		}
+		state, err := recording.State(*frameNumber)
+		if err != nil {
+			log.Fatal(err)
+		}
+		entry := recording.Entry(*frameNumber)
+		log.Printf("frame %d at %v\n", *frameNumber, time.Duration(entry.Timestamp)*time.Millisecond)
+		drawTable(state, header.Rows, header.Columns)
+		return
+	}
+	state := dumpFrames(recording)
+	drawTable(state, header.Rows, header.Columns)
+}
 
+// dumpFrames prints every frame left in decoder and returns the display they build
+func dumpFrames(decoder frameDecoder) *frame.State {
+	state := frame.NewState()
 	for {
-		if err := binary.Read(file, binary.BigEndian, &ledInfo); err != nil {
-			log.Fatal("error reading in the led info", err)
-			return
+		ledFrame, err := decoder.Decode()
+		if err == io.EOF {
+			if d, ok := decoder.(*frame.Decoder); ok {
+				log.Printf("%+v\n", d.Stats())
+			}
+			return state
+		} else if _, ok := err.(*frame.CorruptFrameError); ok {
+			log.Println("skipping", err)
+			continue
+		} else if err != nil {
+			log.Fatal("error reading in the frame", err)
 		}
-		ledInfos[ScreenKey{ledInfo.Row, ledInfo.Column}] = ledInfo
-		fmt.Printf("%+v\n", ledInfo)
-		// drawTable(ledInfos)
+		fmt.Printf("%+v key:%t\n", ledFrame.Header, ledFrame.Header.IsKeyFrame())
+		for _, ledInfo := range ledFrame.LEDs {
+			fmt.Printf("%+v\n", ledInfo)
+		}
+		state.Apply(ledFrame)
 	}
 }
 
-func drawTable(leds map[ScreenKey]frame.LEDInfo, numRows uint8, numColumns uint8) {
-	log.Printf("%dx%d -> %v\n", numRows, numColumns, leds)
+// drawTable prints the display rebuilt from the frames read.  A grid size of 0
+// is worked out from the LEDs that were lit.
+func drawTable(state *frame.State, numRows, numColumns uint16) {
+	if numRows == 0 || numColumns == 0 {
+		for _, led := range state.LEDs() {
+			if led.Row >= numRows {
+				numRows = led.Row + 1
+			}
+			if led.Column >= numColumns {
+				numColumns = led.Column + 1
+			}
+		}
+	}
+	log.Printf("%dx%d synced:%t\n", numRows, numColumns, state.Synced())
 	black := color.BgBlack.Sprint("  ")
-	for row := uint8(1); row <= numRows; row++ {
+	for row := uint16(0); row < numRows; row++ {
 		fmt.Printf("%02d:", row)
 		var sb strings.Builder
-		for column := uint8(1); column <= numColumns; column++ {
-			if led, ok := leds[ScreenKey{row, column}]; ok {
+		for column := uint16(0); column < numColumns; column++ {
+			if led, ok := state.Get(row, column); ok {
 				c := color.RGB(led.Red, led.Green, led.Blue, true)
 				sb.WriteString(c.Sprintf("  "))
 			} else {
 				sb.WriteString(black)
 			}
-			fmt.Printf(sb.String())
-			sb.Reset()
 		}
-		fmt.Printf(":%02d\n", row)
+		fmt.Printf("%s:%02d\n", sb.String(), row)
 	}
 }
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/transport"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// exportAnimation writes the frames of t to name.gif and, as a recording of
+// header, name.data, with each frame's LEDs at brightness from 0 to 1 and
+// encoded as settings would send them
+func exportAnimation(name string, t *timeline, header frame.ContainerHeader, settings *frame.Encoder, brightness float32) error {
+	file, err := os.Create(name + ".gif")
+	if err != nil {
+		return err
+	}
+	if err := exportGIF(file, t.frames); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	recording, err := transport.CreateFile(name+".data", &header)
+	if err != nil {
+		return err
+	}
+	if err := exportRecording(recording, t.frames, settings, brightness); err != nil {
+		recording.Close()
+		return err
+	}
+	return recording.Close()
+}
+
+// exportGIF writes frames as an animated GIF of one pixel per square that
+// loops forever.  Blank squares are transparent.
+func exportGIF(w io.Writer, frames []*timelineFrame) error {
+	g := &gif.GIF{}
+	for _, f := range frames {
+		img := f.canvas.image()
+		paletted := image.NewPaletted(img.Bounds(), gifPalette(img))
+		draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, image.Point{})
+		g.Image = append(g.Image, paletted)
+		// GIF delays are in hundredths of a second
+		g.Delay = append(g.Delay, int((f.shownFor()+5*time.Millisecond)/(10*time.Millisecond)))
+		// each frame is whole, so clear the last one rather than drawing over it
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+	}
+	return gif.EncodeAll(w, g)
+}
+
+// gifPalette the colors of img when they fit in a GIF palette, or a standard
+// palette to dither them to when there are too many; the colors that fit are
+// matched exactly, leaving nothing to diffuse
+func gifPalette(img *image.NRGBA) color.Palette {
+	p := color.Palette{color.Transparent}
+	seen := map[color.NRGBA]bool{}
+	for i := 0; i < len(img.Pix); i += 4 {
+		c := color.NRGBA{R: img.Pix[i], G: img.Pix[i+1], B: img.Pix[i+2], A: img.Pix[i+3]}
+		if c.A == 0 || seen[c] {
+			continue
+		}
+		if len(p) == 256 {
+			return append(color.Palette{color.Transparent}, palette.WebSafe...)
+		}
+		seen[c] = true
+		p = append(p, c)
+	}
+	return p
+}
+
+// exportRecording writes frames as key frames, each at its time along the
+// timeline, at brightness and with the payload and calibration settings of settings
+func exportRecording(w io.Writer, frames []*timelineFrame, settings *frame.Encoder, brightness float32) error {
+	enc := frame.NewEncoder(w)
+	enc.Encoding, enc.PixelFormat, enc.WideCoordinates = settings.Encoding, settings.PixelFormat, settings.WideCoordinates
+	enc.Calibration, enc.PowerLimit = settings.Calibration, settings.PowerLimit
+	var at time.Duration
+	for _, f := range frames {
+		duration := f.shownFor() / time.Millisecond
+		if duration > math.MaxUint16 {
+			duration = math.MaxUint16
+		}
+		ledFrame := frame.Frame{
+			Header: frame.Header{Timestamp: uint32(at / time.Millisecond), Duration: uint16(duration)},
+			LEDs:   make([]frame.LEDInfo, 0, len(f.canvas.Squares)),
+		}
+		for _, square := range f.canvas.Squares {
+			ledFrame.LEDs = append(ledFrame.LEDs, ledFromSquare(square.GridCord, square.Color, 1, brightness))
+		}
+		if err := enc.Encode(ledFrame); err != nil {
+			return err
+		}
+		at += f.shownFor()
+	}
+	return nil
+}
+
+// ledFromSquare the LED showing a square drawn in c.  Its brightness is the
+// alpha of c scaled by fade and brightness, each from 0 to 1.
+func ledFromSquare(cord GridCord, c rl.Color, fade, brightness float32) frame.LEDInfo {
+	return frame.LEDInfo{
+		Column:     cord.Column,
+		Row:        cord.Row,
+		Brightness: uint8(float32(c.A)*unit(fade)*unit(brightness) + 0.5),
+		Red:        c.R,
+		Blue:       c.B,
+		Green:      c.G,
+	}
+}
+
+// unit v clamped to 0 to 1
+func unit(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
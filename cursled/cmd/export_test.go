@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/picture"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func twoFrames() *timeline {
+	tl := newTimeline(canvas{Rows: 1, Columns: 2, Squares: []SquareInfo{
+		{GridCord: GridCord{Column: 0}, Color: rl.Red},
+	}}, 150*time.Millisecond, 10)
+	tl.insert(tl.newFrame(canvas{Rows: 1, Columns: 2, Squares: []SquareInfo{
+		{GridCord: GridCord{Column: 1}, Color: rl.Blue},
+	}}, 0))
+	return tl
+}
+
+func TestExportGIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportGIF(&buf, twoFrames().frames); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := picture.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("%d frames, want 2", len(frames))
+	}
+	want := []struct {
+		delay       time.Duration
+		left, right color.NRGBA
+	}{
+		{150 * time.Millisecond, color.NRGBA(rl.Red), color.NRGBA{}},
+		{defaultFrameDuration, color.NRGBA{}, color.NRGBA(rl.Blue)},
+	}
+	for i, w := range want {
+		f := frames[i]
+		if f.Delay != w.delay || f.Image.NRGBAAt(0, 0) != w.left || f.Image.NRGBAAt(1, 0) != w.right {
+			t.Errorf("frame %d shown for %v is %v %v, want %v %v %v", i, f.Delay,
+				f.Image.NRGBAAt(0, 0), f.Image.NRGBAAt(1, 0), w.delay, w.left, w.right)
+		}
+	}
+}
+
+func TestExportRecording(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "animation")
+	header := frame.ContainerHeader{Rows: 1, Columns: 2}
+	if err := exportAnimation(name, twoFrames(), header, frame.NewEncoder(nil), 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name + ".gif"); err != nil {
+		t.Error(err)
+	}
+
+	file, err := os.Open(name + ".data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := frame.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 2 {
+		t.Fatalf("%d frames, want 2", r.Len())
+	}
+	for i, want := range []struct {
+		timestamp uint32
+		duration  uint16
+		led       frame.LEDInfo
+	}{
+		{0, 150, frame.LEDInfo{Column: 0, Red: 230, Green: 41, Blue: 55, Brightness: 128}},
+		{150, 100, frame.LEDInfo{Column: 1, Red: 0, Green: 121, Blue: 241, Brightness: 128}},
+	} {
+		f, err := r.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Entry(i).IsKeyFrame() || f.Header.Timestamp != want.timestamp || f.Header.Duration != want.duration {
+			t.Errorf("frame %d at %dms for %dms, want a key frame at %dms for %dms", i,
+				f.Header.Timestamp, f.Header.Duration, want.timestamp, want.duration)
+		}
+		if len(f.LEDs) != 1 || f.LEDs[0] != want.led {
+			t.Errorf("frame %d LEDs %v, want %v", i, f.LEDs, want.led)
+		}
+	}
+}
+
+func TestLEDFromSquare(t *testing.T) {
+	tests := []struct {
+		name             string
+		alpha            uint8
+		fade, brightness float32
+		want             uint8
+	}{
+		{"full", 255, 1, 1, 255},
+		{"painted at half alpha", 128, 1, 1, 128},
+		{"half brightness", 255, 1, 0.5, 128},
+		{"all three multiply", 200, 0.5, 0.5, 50},
+		{"decayed", 255, 0, 1, 0},
+		{"out of range is clamped", 100, 2, -1, 0},
+	}
+	for _, tt := range tests {
+		led := ledFromSquare(GridCord{Row: 1, Column: 2}, rl.NewColor(10, 20, 30, tt.alpha), tt.fade, tt.brightness)
+		want := frame.LEDInfo{Row: 1, Column: 2, Red: 10, Green: 20, Blue: 30, Brightness: tt.want}
+		if led != want {
+			t.Errorf("%s: %v, want %v", tt.name, led, want)
+		}
+	}
+}
+
+func TestFadeFactor(t *testing.T) {
+	defer func(d time.Duration) { decayTime = d }(decayTime)
+	decayTime = time.Minute
+	half := SquareInfo{Color: rl.NewColor(1, 2, 3, 200), CreatedAt: time.Now().Add(-30 * time.Second)}
+	if fade := fadeFactor(half, true, true); fade < 0.49 || fade > 0.51 {
+		t.Errorf("half way through decaying faded to %v", fade)
+	}
+	if c := fadeAndDecay(half, true, true); c.A < 99 || c.A > 101 || c.R != 1 {
+		t.Errorf("half faded square of alpha 200 drawn as %v", c)
+	}
+	if fade := fadeFactor(half, false, true); fade != 1 {
+		t.Errorf("decaying without fading faded to %v", fade)
+	}
+	old := SquareInfo{Color: rl.Red, CreatedAt: time.Now().Add(-time.Hour)}
+	if fade := fadeFactor(old, true, true); fade != 0 || fadeAndDecay(old, true, true) != rl.Blank {
+		t.Errorf("decayed square faded to %v", fade)
+	}
+	if fade := fadeFactor(old, true, false); fade != 1 || fadeAndDecay(old, true, false) != rl.Red {
+		t.Errorf("square faded to %v without decay", fade)
+	}
+}
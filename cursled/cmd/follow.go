@@ -15,6 +15,19 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/layout"
+	"github.com/aaronbush/go-stuff/cursled/transport"
+	rl "github.com/gen2brain/raylib-go/raylib"
+
 	"github.com/spf13/cobra"
 )
 
@@ -23,16 +36,176 @@ var followCmd = &cobra.Command{
 	Use:   "follow",
 	Short: "A read-only follower to show what should be drawn",
 	Long: `A way to test and see that what we think should be 
-	drawn is sent correctly to the binary bytestream`,
+	drawn is sent correctly to the binary bytestream.  Each LED is
+	drawn where it sits, as given by the wiring or --layout file.`,
 	RunE: follow,
 }
 
 func init() {
 	rootCmd.AddCommand(followCmd)
 
+	followCmd.Flags().String("from", "test.data", "file://, serial://, tcp:// or udp:// URL to read frames from")
+	followCmd.Flags().Int32P("rows", "r", 40, "number of rows")
+	followCmd.Flags().Int32P("columns", "c", 20, "number of columns")
+	followCmd.Flags().Int32P("size", "s", 600, "longest side of the preview window")
+	addLayoutFlag(followCmd)
+}
+
+// ledSpot where the preview draws one LED and the grid cell it shows
+type ledSpot struct {
+	Center rl.Vector2
+	Cell   GridCord
 }
 
 func follow(cmd *cobra.Command, args []string) error {
+	from, _ := cmd.Flags().GetString("from")
+	rows, _ := cmd.Flags().GetInt32("rows")
+	columns, _ := cmd.Flags().GetInt32("columns")
+	size, _ := cmd.Flags().GetInt32("size")
+	if rows < 1 || columns < 1 || size < 1 {
+		return fmt.Errorf("%dx%d grid in a %d pixel window can't be drawn", rows, columns, size)
+	}
+
+	wiring, err := loadLayout(cmd, int(rows), int(columns))
+	if err != nil {
+		return err
+	}
+	spots, width, height, radius := layoutSpots(wiring, float32(size))
+
+	source, err := transport.Listen(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	var mu sync.Mutex
+	state := frame.NewState()
+	done := make(chan error, 1)
+	go func() {
+		done <- followFrames(source, state, &mu)
+	}()
+
+	rl.InitWindow(width, height, "following "+from)
+	rl.SetTargetFPS(30)
 
+	status := "following"
+	for !rl.WindowShouldClose() {
+		select {
+		case err := <-done:
+			if status = "stream ended"; err != nil {
+				status = err.Error()
+				log.Error("reading frames: ", err)
+			}
+		default:
+		}
+
+		rl.BeginDrawing()
+		rl.ClearBackground(rl.Black)
+		mu.Lock()
+		for _, spot := range spots {
+			if led, ok := state.Get(spot.Cell.Row, spot.Cell.Column); ok {
+				c := led.RGBA()
+				rl.DrawCircleV(spot.Center, radius, rl.NewColor(c.R, c.G, c.B, 255))
+			}
+			rl.DrawCircleLines(int32(spot.Center.X), int32(spot.Center.Y), radius, rl.DarkGray)
+		}
+		synced := state.Synced()
+		mu.Unlock()
+		rl.DrawText(fmt.Sprintf("%s, synced:%t", status, synced), 3, 3, 12, rl.Gray)
+		rl.EndDrawing()
+	}
+
+	rl.CloseWindow()
 	return nil
 }
+
+// layoutSpots works out where each LED of l is drawn in a window whose longest
+// side is size, at its real position when l knows it and at the middle of its
+// cell otherwise, and how big the LEDs are drawn
+func layoutSpots(l layout.Layout, size float32) ([]ledSpot, int32, int32, float32) {
+	positions := make([]layout.Point, 0, l.Len())
+	cells := make([]GridCord, 0, l.Len())
+	positioned, hasPositions := l.(layout.Positioned)
+	min, max := layout.Point{}, layout.Point{}
+	for i := 0; i < l.Len(); i++ {
+		row, column, ok := l.Cell(i)
+		if !ok {
+			continue
+		}
+		p := layout.Point{X: float64(column) + 0.5, Y: float64(row) + 0.5}
+		if hasPositions {
+			if p, ok = positioned.Position(i); !ok {
+				continue
+			}
+		}
+		if len(positions) == 0 {
+			min, max = p, p
+		}
+		min.X, min.Y = math.Min(min.X, p.X), math.Min(min.Y, p.Y)
+		max.X, max.Y = math.Max(max.X, p.X), math.Max(max.Y, p.Y)
+		positions = append(positions, p)
+		cells = append(cells, GridCord{Row: uint16(row), Column: uint16(column)})
+	}
+
+	// leave half the gap between LEDs around the edge, guessing the gap from how
+	// densely they fill their bounds
+	width, height := max.X-min.X, max.Y-min.Y
+	gap := 1.0
+	if n := float64(len(positions)); n > 1 {
+		if width > 0 && height > 0 {
+			gap = math.Sqrt(width * height / n)
+		} else {
+			gap = (width + height) / (n - 1)
+		}
+	}
+	if gap <= 0 {
+		gap = 1
+	}
+	min.X, min.Y = min.X-gap/2, min.Y-gap/2
+	width, height = width+gap, height+gap
+	scale := float64(size) / math.Max(width, height)
+
+	spots := make([]ledSpot, len(positions))
+	for i, p := range positions {
+		spots[i] = ledSpot{
+			Center: rl.NewVector2(float32((p.X-min.X)*scale), float32((p.Y-min.Y)*scale)),
+			Cell:   cells[i],
+		}
+	}
+	radius := float32(math.Max(gap*scale*0.4, 2))
+	return spots, int32(math.Ceil(width * scale)), int32(math.Ceil(height * scale)), radius
+}
+
+// followFrames applies each frame read from source to state, keeping to the
+// timestamps of versioned frames so a recording plays back at its own speed
+func followFrames(source io.Reader, state *frame.State, mu *sync.Mutex) error {
+	decoder := frame.NewDecoder(source)
+	var startedAt time.Time
+	var firstTimestamp uint32
+	for {
+		ledFrame, err := decoder.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		switch err.(type) {
+		case nil:
+		case *frame.OversizeError, *frame.UnsupportedVersionError, *frame.UnsupportedEncodingError, *frame.UnsupportedPixelFormatError:
+			log.Warn("discarding frame: ", err)
+			continue
+		default:
+			return err
+		}
+
+		if ledFrame.Header.Version >= frame.Version2 {
+			if startedAt.IsZero() || ledFrame.Header.Timestamp < firstTimestamp {
+				// the first frame, or a sender that started over
+				startedAt, firstTimestamp = time.Now(), ledFrame.Header.Timestamp
+			}
+			due := time.Duration(ledFrame.Header.Timestamp-firstTimestamp) * time.Millisecond
+			time.Sleep(time.Until(startedAt.Add(due)))
+		}
+		mu.Lock()
+		state.Apply(ledFrame)
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+// edit the squares changed by one action on the canvas, such as a stroke, a
+// flood fill or a clear, as they were before and after it
+type edit struct {
+	before, after map[GridCord]SquareInfo
+}
+
+// history the edits made to the canvas, to undo and redo.  It remembers at
+// most limit squares across all edits, forgetting the oldest edits first.
+type history struct {
+	undone, done []edit
+	pending      *edit // the edit being made, e.g. a stroke while the button is down
+	limit        int
+	size         int // squares remembered by done and undone
+}
+
+func newHistory(limit int) *history {
+	return &history{limit: limit}
+}
+
+// begin starts an edit; squares changed until end are undone together
+func (h *history) begin() {
+	if h.pending == nil {
+		h.pending = &edit{before: make(map[GridCord]SquareInfo)}
+	}
+}
+
+// editing reports whether an edit has begun and not ended
+func (h *history) editing() bool {
+	return h.pending != nil
+}
+
+// save remembers square as it was before the edit first changes it
+func (h *history) save(square SquareInfo) {
+	if h.pending == nil {
+		return
+	}
+	if _, ok := h.pending.before[square.GridCord]; !ok {
+		h.pending.before[square.GridCord] = square
+	}
+}
+
+// saveAll remembers every square, for edits like fills and clears that change many
+func (h *history) saveAll(gridContents map[GridCord]SquareInfo) {
+	for _, square := range gridContents {
+		h.save(square)
+	}
+}
+
+// end finishes the edit, keeping the squares it changed as they are now in
+// gridContents.  An edit that changed nothing is dropped; any other makes the
+// edits undone before it impossible to redo.
+func (h *history) end(gridContents map[GridCord]SquareInfo) {
+	if h.pending == nil {
+		return
+	}
+	e := edit{before: h.pending.before, after: make(map[GridCord]SquareInfo)}
+	h.pending = nil
+	for cord, before := range e.before {
+		after := gridContents[cord]
+		if after.Color == before.Color {
+			delete(e.before, cord)
+			continue
+		}
+		e.after[cord] = after
+	}
+	if len(e.before) == 0 {
+		return
+	}
+	for _, undone := range h.undone {
+		h.size -= len(undone.before)
+	}
+	h.undone = nil
+	h.done = append(h.done, e)
+	h.size += len(e.before)
+	// the edit just made is kept even when it alone is over the limit
+	for h.size > h.limit && len(h.done) > 1 {
+		h.size -= len(h.done[0].before)
+		h.done[0] = edit{} // let the backing array forget it
+		h.done = h.done[1:]
+	}
+}
+
+// undo puts back the squares changed by the last edit done
+func (h *history) undo(gridContents map[GridCord]SquareInfo) bool {
+	h.end(gridContents)
+	if len(h.done) == 0 {
+		return false
+	}
+	e := h.done[len(h.done)-1]
+	h.done[len(h.done)-1] = edit{}
+	h.done = h.done[:len(h.done)-1]
+	for cord, square := range e.before {
+		gridContents[cord] = square
+	}
+	h.undone = append(h.undone, e)
+	return true
+}
+
+// redo makes the last edit undone again
+func (h *history) redo(gridContents map[GridCord]SquareInfo) bool {
+	h.end(gridContents)
+	if len(h.undone) == 0 {
+		return false
+	}
+	e := h.undone[len(h.undone)-1]
+	h.undone[len(h.undone)-1] = edit{}
+	h.undone = h.undone[:len(h.undone)-1]
+	for cord, square := range e.after {
+		gridContents[cord] = square
+	}
+	h.done = append(h.done, e)
+	return true
+}
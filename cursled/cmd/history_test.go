@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// paintSquares colors squares of gridContents as one edit
+func paintSquares(h *history, gridContents map[GridCord]SquareInfo, color rl.Color, cords ...GridCord) {
+	h.begin()
+	for _, cord := range cords {
+		square := gridContents[cord]
+		h.save(square)
+		square.Color = color
+		gridContents[cord] = square
+	}
+	h.end(gridContents)
+}
+
+func colorAt(gridContents map[GridCord]SquareInfo, column uint16) rl.Color {
+	return gridContents[GridCord{Column: column}].Color
+}
+
+func TestHistoryUndoRedo(t *testing.T) {
+	gridContents := makeGridLine(4)
+	h := newHistory(100)
+	paintSquares(h, gridContents, rl.Red, GridCord{Column: 0}, GridCord{Column: 1}, GridCord{Column: 0})
+	paintSquares(h, gridContents, rl.Blue, GridCord{Column: 1}, GridCord{Column: 2})
+
+	if !h.undo(gridContents) {
+		t.Fatal("nothing to undo")
+	}
+	if colorAt(gridContents, 1) != rl.Red || colorAt(gridContents, 2) != rl.Black {
+		t.Errorf("undoing the second stroke left %v", gridContents)
+	}
+	h.undo(gridContents)
+	if colorAt(gridContents, 0) != rl.Black || colorAt(gridContents, 1) != rl.Black {
+		t.Errorf("undoing the first stroke left %v", gridContents)
+	}
+	if h.undo(gridContents) {
+		t.Error("undid more edits than were made")
+	}
+
+	h.redo(gridContents)
+	if colorAt(gridContents, 0) != rl.Red || colorAt(gridContents, 1) != rl.Red {
+		t.Errorf("redoing the first stroke left %v", gridContents)
+	}
+
+	// a new edit can't be followed by redoing the old second stroke
+	paintSquares(h, gridContents, rl.Green, GridCord{Column: 3})
+	if h.redo(gridContents) {
+		t.Error("redid an edit after a new one was made")
+	}
+	if h.size != 3 {
+		t.Errorf("history remembers %d squares, want 3", h.size)
+	}
+}
+
+func TestHistoryGroupsFills(t *testing.T) {
+	gridContents := makeGridLine(5)
+	h := newHistory(100)
+	h.begin()
+	h.saveAll(gridContents)
+	if changed := floodFill(gridContents, gridContents[GridCord{Column: 2}], rl.Red); changed == 0 {
+		t.Fatal("nothing filled")
+	}
+	h.end(gridContents)
+	paintSquares(h, gridContents, rl.Red) // changes nothing and isn't kept
+
+	h.undo(gridContents)
+	for column := uint16(0); column < 5; column++ {
+		if colorAt(gridContents, column) != rl.Black {
+			t.Errorf("square %d is %v after undoing the fill", column, colorAt(gridContents, column))
+		}
+	}
+	if h.undo(gridContents) {
+		t.Error("an edit changing nothing was kept")
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	gridContents := makeGridLine(4)
+	h := newHistory(3)
+	paintSquares(h, gridContents, rl.Red, GridCord{Column: 0}, GridCord{Column: 1})
+	paintSquares(h, gridContents, rl.Blue, GridCord{Column: 2}, GridCord{Column: 3})
+	if len(h.done) != 1 || h.size != 2 {
+		t.Fatalf("%d edits of %d squares kept, want the newest of 2", len(h.done), h.size)
+	}
+	// an edit bigger than the limit is still kept
+	paintSquares(h, gridContents, rl.Green, GridCord{Column: 0}, GridCord{Column: 1}, GridCord{Column: 2}, GridCord{Column: 3})
+	if len(h.done) != 1 || h.size != 4 {
+		t.Errorf("%d edits of %d squares kept, want the newest of 4", len(h.done), h.size)
+	}
+	// edits moved off the end of done aren't kept alive by its backing array
+	h.undo(gridContents)
+	if forgotten := h.done[:1][0]; forgotten.before != nil || forgotten.after != nil {
+		t.Error("the undone edit is still held past the end of done")
+	}
+}
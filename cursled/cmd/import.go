@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aaronbush/go-stuff/cursled/picture"
+)
+
+// importPicture reads a PNG, JPEG or GIF resampled to the grid, one frame for
+// each frame of an animated GIF
+func importPicture(path string, rows, columns uint16, fit, filter string) ([]*timelineFrame, error) {
+	o := picture.Options{Rows: int(rows), Columns: int(columns)}
+	var err error
+	if o.Fit, err = picture.ParseFit(fit); err != nil {
+		return nil, err
+	}
+	if o.Filter, err = picture.ParseFilter(filter); err != nil {
+		return nil, err
+	}
+	pictures, err := picture.Open(path, o)
+	if err != nil {
+		return nil, err
+	}
+	if len(pictures) == 0 {
+		return nil, fmt.Errorf("%s has no frames", path)
+	}
+	frames := make([]*timelineFrame, len(pictures))
+	for i, p := range pictures {
+		c, err := canvasFromImage(p.Image)
+		if err != nil {
+			return nil, err
+		}
+		frames[i] = &timelineFrame{canvas: c, duration: p.Delay}
+	}
+	return frames, nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"io"
+
+	_ "github.com/aaronbush/go-stuff/cursled/output" // registers the lighting protocol URLs
+	"github.com/aaronbush/go-stuff/cursled/transport"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// The outputs key of the config file lists URLs like the --output flag, e.g.
+//
+//	outputs:
+//	  - tcp://ledpanel.local:7890
+//	  - udp://192.168.1.50:7890?mtu=1400
+//	  - e131://?universe=1&priority=100
+//	  - artnet://192.168.1.60?universe=0&offset=0
+//	  - ddp://wled-panel.local
+//	  - wled://192.168.1.70?mode=dnrgb&timeout=2
+
+// serialOutputConfig the serial section of the config file, e.g.
+//
+//	serial:
+//	  device: /dev/ttyUSB0
+//	  baud: 921600
+//	  framing: 8N1
+//	  flowControl: none
+type serialOutputConfig struct {
+	Device      string `mapstructure:"device"`
+	Baud        int    `mapstructure:"baud"`
+	Framing     string `mapstructure:"framing"`
+	FlowControl string `mapstructure:"flowControl"`
+}
+
+// addOutputFlags adds the flags choosing where a command sends its frames besides its log
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("output", "o", nil, "file://, serial://, tcp://, udp://, e131://, artnet://, ddp:// or wled:// URL to send frames to; may be repeated")
+	cmd.Flags().String("serial", "", "serial device to stream frames to, e.g. /dev/ttyUSB0")
+	cmd.Flags().Int("baud", transport.DefaultBaud, "serial baud rate")
+	cmd.Flags().String("framing", "8N1", "serial data bits, parity and stop bits")
+	cmd.Flags().String("flowControl", string(transport.FlowNone), "serial flow control: none, rtscts or xonxoff")
+}
+
+// openOutputs opens the outputs chosen by the flags added with addOutputFlags or
+// the config file; the flags of the running command take precedence.  display
+// describes the grid to file outputs that record it, and every output sends the
+// LEDs in the order its wiring chains them.
+func openOutputs(cmd *cobra.Command, display transport.Display) ([]transport.Transport, error) {
+	viper.BindPFlag("outputs", cmd.Flags().Lookup("output"))
+	var outputs []transport.Transport
+	closeAll := func() {
+		for _, output := range outputs {
+			output.Close()
+		}
+	}
+	for _, url := range viper.GetStringSlice("outputs") {
+		output, err := transport.Open(url, display)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		outputs = append(outputs, output)
+	}
+
+	viper.BindPFlag("serial.device", cmd.Flags().Lookup("serial"))
+	viper.BindPFlag("serial.baud", cmd.Flags().Lookup("baud"))
+	viper.BindPFlag("serial.framing", cmd.Flags().Lookup("framing"))
+	viper.BindPFlag("serial.flowControl", cmd.Flags().Lookup("flowControl"))
+
+	config := serialOutputConfig{}
+	if err := viper.UnmarshalKey("serial", &config); err != nil {
+		closeAll()
+		return nil, err
+	}
+	if config.Device == "" {
+		return outputs, nil
+	}
+	serialConfig := transport.DefaultSerialConfig(config.Device)
+	serialConfig.Baud = config.Baud
+	serialConfig.FlowControl = transport.FlowControl(config.FlowControl)
+	if err := serialConfig.ParseFraming(config.Framing); err != nil {
+		closeAll()
+		return nil, err
+	}
+	serial, err := transport.OpenSerial(serialConfig)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	return append(outputs, transport.Wire(serial, display)), nil
+}
+
+// fanOut writes each frame to the binary log and then to every output.  Only the
+// log's errors are returned; an output that fails is logged and its frames are
+// dropped and counted, so an unplugged board doesn't stop the others.
+type fanOut struct {
+	binaryLog io.Writer
+	outputs   []io.Writer
+	dropped   []int // frames each output has failed to take since it last took one
+}
+
+func newFanOut(binaryLog io.Writer, outputs []io.Writer) *fanOut {
+	return &fanOut{binaryLog: binaryLog, outputs: outputs, dropped: make([]int, len(outputs))}
+}
+
+func (f *fanOut) Write(p []byte) (int, error) {
+	if _, err := f.binaryLog.Write(p); err != nil {
+		return 0, err
+	}
+	for i, output := range f.outputs {
+		if _, err := output.Write(p); err != nil {
+			if f.dropped[i] == 0 {
+				log.Warnf("%v: %v; dropping frames until it takes them again", output, err)
+			}
+			f.dropped[i]++
+		} else if f.dropped[i] > 0 {
+			log.Infof("%v is taking frames again after dropping %d", output, f.dropped[i])
+			f.dropped[i] = 0
+		}
+	}
+	return len(p), nil
+}
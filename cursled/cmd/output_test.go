@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// failingWriter fails while down is set
+type failingWriter struct {
+	bytes.Buffer
+	down bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.down {
+		return 0, errors.New("unplugged")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestFanOutDropsFailingOutputs(t *testing.T) {
+	binaryLog, unplugged, working := &failingWriter{}, &failingWriter{down: true}, &failingWriter{}
+	out := newFanOut(binaryLog, []io.Writer{unplugged, working})
+	for _, p := range []string{"a", "b"} {
+		if _, err := out.Write([]byte(p)); err != nil {
+			t.Fatalf("Write(%q) = %v, want the failing output dropped", p, err)
+		}
+	}
+	if binaryLog.String() != "ab" || working.String() != "ab" || out.dropped[0] != 2 {
+		t.Errorf("log %q, working output %q, %d dropped; want ab, ab, 2", binaryLog.String(), working.String(), out.dropped[0])
+	}
+
+	unplugged.down = false
+	out.Write([]byte("c"))
+	if unplugged.String() != "c" || out.dropped[0] != 0 {
+		t.Errorf("recovered output got %q with %d dropped, want c with 0", unplugged.String(), out.dropped[0])
+	}
+
+	binaryLog.down = true
+	if _, err := out.Write([]byte("d")); err == nil {
+		t.Error("Write accepted a frame the binary log failed to take")
+	}
+}
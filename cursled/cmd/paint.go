@@ -15,21 +15,22 @@
 package cmd
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/picture"
+	"github.com/aaronbush/go-stuff/cursled/transport"
 	rg "github.com/gen2brain/raylib-go/raygui"
 	rl "github.com/gen2brain/raylib-go/raylib"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 type SquareInfo struct {
@@ -40,8 +41,8 @@ type SquareInfo struct {
 }
 
 type GridCord struct {
-	Row    uint8
-	Column uint8
+	Row    uint16
+	Column uint16
 }
 
 const (
@@ -61,14 +62,28 @@ var (
 	gridColor     = rl.RayWhite
 	decayMode     = false
 	binaryLog     string
+	keyInterval   int
+	keepalive     time.Duration
+	undoLimit     int
+	canvasFile    string
+	palettePath   string
+	startCanvas   *canvas // opened with --open, setting the grid size
+	importFile    string
+	importFit     string
+	importFilter  string
+	frameDuration time.Duration
+	exportName    string
+	protocol      int
+	wideCords     bool
 )
 
 // paintCmd represents the paint command
 var paintCmd = &cobra.Command{
-	Use:   "paint",
-	Short: "Start the paint UI",
-	Long:  `Start the paint-like UI which allows drawing directly on the LED display`,
-	RunE:  paint,
+	Use:     "paint",
+	Short:   "Start the paint UI",
+	Long:    `Start the paint-like UI which allows drawing directly on the LED display`,
+	PreRunE: validatePaintFlags,
+	RunE:    paint,
 }
 
 func init() {
@@ -78,13 +93,71 @@ func init() {
 	paintCmd.Flags().Int32VarP(&numRows, "rows", "r", 40, "number of rows")
 	paintCmd.Flags().Int32VarP(&numColumns, "columns", "c", 20, "number of columns")
 	paintCmd.Flags().Int32VarP(&spacing, "spacing", "s", 20, "cell spacing")
-	paintCmd.Flags().Float32VarP(&maxBrightness, "brightness", "b", 50, "max brightness")
+	paintCmd.Flags().Float32VarP(&maxBrightness, "brightness", "b", 50, "max brightness of the LEDs, in percent; the brightness slider changes it")
 	paintCmd.Flags().DurationVarP(&decayTime, "decayTime", "t", 3*time.Second, "decay time (seconds)")
-	paintCmd.Flags().StringVarP(&binaryLog, "binaryLog", "l", "test.data", "binary log file name")
+	paintCmd.Flags().StringVarP(&binaryLog, "binaryLog", "l", "test.data", "binary log file name; a recording with a seek index unless --protocol is 1")
+	paintCmd.Flags().IntVarP(&keyInterval, "keyInterval", "k", 30, "frames between full key frames in the binary log")
+	paintCmd.Flags().DurationVar(&keepalive, "keepalive", time.Second, "longest gap between frames while the display is unchanged; 0 sends every frame")
+	paintCmd.Flags().StringVar(&canvasFile, "open", "", "PNG or JSON canvas to open; Ctrl+S saves the canvas to it and Ctrl+O opens it again")
+	paintCmd.Flags().StringVar(&palettePath, "palette", "palette.gpl", "GIMP (.gpl) or JSON palette to load at start and to save and load from the palette buttons")
+	paintCmd.Flags().StringVar(&importFile, "import", "", "PNG, JPEG or GIF to put on the canvas; Ctrl+I imports it again")
+	paintCmd.Flags().StringVar(&importFit, "fit", "fit", "how imported pictures are scaled to the grid: fit, fill or stretch")
+	paintCmd.Flags().StringVar(&importFilter, "filter", "area", "how imported pictures are resampled: nearest or area")
+	paintCmd.Flags().DurationVar(&frameDuration, "frameDuration", defaultFrameDuration, "how long new frames of an animation are shown")
+	paintCmd.Flags().StringVar(&exportName, "export", "animation", "name Ctrl+E exports the animation to, as name.gif and a recording name.data")
+	paintCmd.Flags().IntVar(&undoLimit, "undoLimit", 100000, "most squares of edits remembered for undo")
+	paintCmd.Flags().IntVar(&protocol, "protocol", int(frame.CurrentVersion), "frame protocol version for the binary log")
+	paintCmd.Flags().BoolVar(&wideCords, "wideCoordinates", false, "send 16 bit rows and columns; needed beyond 256 rows or columns")
+	paintCmd.Flags().Float64("gamma", frame.DefaultGamma, "gamma correction applied to LED colors")
+	paintCmd.Flags().Bool("noCalibration", false, "send colors as picked, without gamma or white balance")
+	viper.BindPFlag("calibration.gamma", paintCmd.Flags().Lookup("gamma"))
+	viper.BindPFlag("calibration.disabled", paintCmd.Flags().Lookup("noCalibration"))
+	addOutputFlags(paintCmd)
+	addPowerFlags(paintCmd)
+	addLayoutFlag(paintCmd)
 
 	log.SetLevel(log.DebugLevel)
 }
 
+// validatePaintFlags rejects grid sizes that the chosen frame format can't address
+func validatePaintFlags(cmd *cobra.Command, args []string) error {
+	if err := openStartCanvas(cmd); err != nil {
+		return err
+	}
+	if _, err := picture.ParseFit(importFit); err != nil {
+		return err
+	}
+	if _, err := picture.ParseFilter(importFilter); err != nil {
+		return err
+	}
+	if maxBrightness < 0 || maxBrightness > 100 {
+		return fmt.Errorf("brightness %v is not a percentage from 0 to 100", maxBrightness)
+	}
+	if protocol != int(frame.Version1) && protocol != int(frame.Version2) {
+		return fmt.Errorf("unsupported protocol version %d", protocol)
+	}
+	if wideCords && protocol == int(frame.Version1) {
+		return errors.New("wide coordinates need protocol version 2")
+	}
+	maxCord := frame.MaxNarrowCoordinate
+	if wideCords {
+		maxCord = frame.MaxWideCoordinate
+	}
+	if numRows < 1 || numColumns < 1 {
+		return fmt.Errorf("grid must have at least one row and column, not %dx%d", numRows, numColumns)
+	}
+	if int(numRows)-1 > maxCord || int(numColumns)-1 > maxCord {
+		if !wideCords {
+			return fmt.Errorf("%dx%d grid can't be addressed with 8 bit coordinates; use --wideCoordinates", numRows, numColumns)
+		}
+		return fmt.Errorf("%dx%d grid can't be addressed with 16 bit coordinates", numRows, numColumns)
+	}
+	if int(numRows)*int(numColumns) > frame.MaxLEDs {
+		return fmt.Errorf("%dx%d grid has more than the %d LEDs a frame can hold", numRows, numColumns, frame.MaxLEDs)
+	}
+	return nil
+}
+
 func paint(cmd *cobra.Command, args []string) error {
 	gridOrigin := rl.NewVector2(0, 3)
 	gridHeight = numRows * spacing
@@ -93,31 +166,112 @@ func paint(cmd *cobra.Command, args []string) error {
 	statusBarOrigin := rl.NewVector2(gridOrigin.X, gridOrigin.Y+float32(gridHeight))
 	rightControlOrigin := rl.NewVector2(gridOrigin.X+float32(gridWidth), gridOrigin.Y)
 	stausBarHeight := int32(60)
-	rightControlWidth := int32(60)
+	rightControlWidth := int32(pickerWidth + 10)
 
-	windowHeight := gridHeight + stausBarHeight
+	timelineOrigin := rl.NewVector2(statusBarOrigin.X+3, statusBarOrigin.Y+float32(stausBarHeight))
+
+	windowHeight := gridHeight + stausBarHeight + timelineHeight
 	windowWidth := gridWidth + rightControlWidth
 
-	gridContents := makeGridContents(gridOrigin, uint8(numRows), uint8(numColumns))
+	gridContents := makeGridContents(gridOrigin, uint16(numRows), uint16(numColumns))
+	if startCanvas != nil {
+		applyCanvas(gridContents, *startCanvas)
+	}
 	spacingFloat = float32(spacing)
 
-	redValue, greenValue, blueValue := new(int), new(int), new(int)
-	*redValue = 255
+	startPalette, err := loadStartPalette()
+	if err != nil {
+		return err
+	}
+	picker := newColorPicker(rl.NewColor(maxRGB, 0, 0, 255), startPalette)
 
 	fadeMode := false
 	logMode := false
-	floodFillMode := false
-
-	// Open a new file for writing only
-	binaryLogFile, err := os.OpenFile(
-		binaryLog,
-		os.O_WRONLY|os.O_TRUNC|os.O_CREATE,
-		0666,
-	)
+	currentTool, toolBeforePicker := toolPencil, toolPencil
+	filledShapes := false
+	brush, brushRadius := brushSquare, 0
+	stroking := false // a button has been held since the stroke reached strokeAt
+	var strokeAt GridCord
+	shaping := false // a shape is being dragged out from shapeFrom to shapeTo
+	var shapeFrom, shapeTo GridCord
+	animation := newTimeline(canvasFromGrid(gridContents, uint16(numRows), uint16(numColumns)), frameDuration, undoLimit)
+	edits := animation.frame().edits
+	// showFrame keeps the canvas in the current frame, lets change choose another
+	// and puts that one on the canvas
+	showFrame := func(change func()) {
+		edits.end(gridContents)
+		animation.frame().canvas = canvasFromGrid(gridContents, uint16(numRows), uint16(numColumns))
+		change()
+		applyCanvas(gridContents, animation.frame().canvas)
+		edits = animation.frame().edits
+	}
+	// a picture replaces the canvas as one edit; the frames of an animation are
+	// added after the current frame and played
+	importCanvas := func() {
+		frames, err := importPicture(importFile, uint16(numRows), uint16(numColumns), importFit, importFilter)
+		if err != nil {
+			log.Error("importing a picture: ", err)
+			return
+		}
+		log.Infof("imported %d frames of %s", len(frames), importFile)
+		if len(frames) > 1 {
+			showFrame(func() { animation.insert(frames...) })
+			animation.play(time.Now())
+			return
+		}
+		edits.end(gridContents)
+		edits.begin()
+		edits.saveAll(gridContents)
+		applyCanvas(gridContents, frames[0].canvas)
+		edits.end(gridContents)
+	}
+	if importFile != "" {
+		importCanvas()
+	}
+
+	// versioned frames are recorded with a header and seek index; Version1 frames
+	// carry no timing so they are logged as a bare stream for older receivers
+	header := frame.ContainerHeader{Rows: uint16(numRows), Columns: uint16(numColumns), FPS: uint16(fps)}
+	recordingHeader := &header
+	if protocol == int(frame.Version1) {
+		recordingHeader = nil
+	}
+	binaryLogFile, err := transport.CreateFile(binaryLog, recordingHeader)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer binaryLogFile.Close()
+	defer func() {
+		if err := binaryLogFile.Close(); err != nil {
+			log.Error("closing the binary log: ", err)
+		}
+	}()
+
+	wiring, err := loadLayout(cmd, int(numRows), int(numColumns))
+	if err != nil {
+		return err
+	}
+	outputs, err := openOutputs(cmd, transport.Display{Header: header, Layout: wiring, Version: uint8(protocol)})
+	if err != nil {
+		return err
+	}
+	var writers []io.Writer
+	for _, output := range outputs {
+		log.Info("streaming frames to ", output)
+		defer output.Close()
+		writers = append(writers, output)
+	}
+	frameEncoder := frame.NewEncoder(newFanOut(binaryLogFile, writers))
+	frameEncoder.Version = uint8(protocol)
+	frameEncoder.WideCoordinates = wideCords
+	if frameEncoder.Calibration, err = loadCalibration(); err != nil {
+		return err
+	}
+	if frameEncoder.PowerLimit, err = loadPowerLimit(cmd); err != nil {
+		return err
+	}
+	encoder := frame.NewDeltaEncoder(frameEncoder, keyInterval)
+	encoder.Keepalive = keepalive
+	startedAt := time.Now()
 
 	rl.InitWindow(windowWidth, windowHeight, "pixel drawing")
 	rg.LoadGuiStyle("cmd/styles/monokai.style")
@@ -128,58 +282,258 @@ func paint(cmd *cobra.Command, args []string) error {
 		rl.BeginDrawing()
 		rl.ClearBackground(rl.Blank)
 
-		drawColor, decayOrigin := drawColorInputs(rightControlOrigin, redValue, greenValue, blueValue)
-		decayMode, _ = drawDecaySettings(decayOrigin, &decayMode)
+		drawColor, brightnessOrigin := drawColorInputs(rightControlOrigin, picker)
+		decayOrigin := drawBrightnessSettings(brightnessOrigin, &maxBrightness)
+		var toolsOrigin rl.Vector2
+		decayMode, toolsOrigin = drawDecaySettings(decayOrigin, &decayMode)
+		if picked, _ := drawToolPalette(toolsOrigin, currentTool, &filledShapes, &brush, &brushRadius); picked != currentTool {
+			if picked == toolEyedropper {
+				toolBeforePicker = currentTool
+			}
+			currentTool, shaping = picked, false
+		}
 
+		if change := drawTimeline(timelineOrigin, float32(windowWidth)-timelineOrigin.X, animation,
+			canvasFromGrid(gridContents, uint16(numRows), uint16(numColumns))); change != nil {
+			showFrame(change)
+		}
+		if animation.due(time.Now()) {
+			showFrame(func() { animation.step(1) })
+		}
+		if previous, ok := animation.previous(); ok && animation.onionSkin && !animation.playing {
+			drawOnionSkin(gridContents, previous.canvas)
+		}
 		drawSquares(gridContents, fadeMode, decayMode)
+		if shaping {
+			drawShapePreview(gridContents, currentTool.shapeCords(shapeFrom, shapeTo, filledShapes, uint16(numRows), uint16(numColumns)), drawColor)
+		}
 
 		drawGrid(gridOrigin, numRows, numColumns) // after colors are drawn to keep grid lines
 
-		statusText := fmt.Sprintf("fade:%t, log:%t, decay:%t\nFPS: %.1f (%.03f)", fadeMode, logMode, decayMode, rl.GetFPS(), rl.GetFrameTime())
-		rl.DrawText(statusText, int32(statusBarOrigin.X+3), int32(statusBarOrigin.Y), 12, rl.Gray)
-
+		for _, output := range outputs {
+			if r, ok := output.(transport.Reconnector); ok && r.Reconnected() {
+				encoder.KeyFrame() // the receiver has nothing to apply deltas to
+			}
+		}
 		if logMode {
-			exportSquares(binaryLogFile, gridContents, fadeMode, decayMode)
+			exportSquares(encoder, time.Since(startedAt), gridContents, fadeMode, decayMode, maxBrightness/100)
 		}
 
-		if rl.IsKeyPressed(rl.KeyF) {
+		statusText := fmt.Sprintf("fade:%t, log:%t, decay:%t, tool:%s, brush:%s %d\nFPS: %.1f (%.03f)",
+			fadeMode, logMode, decayMode, currentTool, brush, brushRadius, rl.GetFPS(), rl.GetFrameTime())
+		statusText += fmt.Sprintf("\nframe %d/%d %v, playing:%t, onion:%t", animation.current+1, len(animation.frames),
+			animation.frame().shownFor(), animation.playing, animation.onionSkin)
+		statusColor := rl.Gray
+		if frameEncoder.PowerLimit != nil && logMode {
+			power := frameEncoder.Power()
+			statusText += fmt.Sprintf("\npower: %s", power)
+			if power.Limited() {
+				statusColor = rl.Orange
+			}
+		}
+		rl.DrawText(statusText, int32(statusBarOrigin.X+3), int32(statusBarOrigin.Y), 12, statusColor)
+
+		// the text boxes take keys while the mouse is over them, so shortcuts wait
+		// until it leaves the controls
+		typing := rl.GetMousePosition().X >= rightControlOrigin.X
+		shortcut := func(key int32) bool { return !typing && rl.IsKeyPressed(key) }
+
+		if shortcut(rl.KeyF) {
 			fadeMode = !fadeMode
 		}
 
-		if rl.IsKeyPressed(rl.KeyL) {
+		if shortcut(rl.KeyL) {
 			logMode = !logMode
 		}
 
-		if rl.IsKeyPressed(rl.KeyB) {
-			floodFillMode = !floodFillMode
+		if shortcut(rl.KeyB) {
+			if currentTool == toolFill {
+				currentTool = toolPencil
+			} else {
+				currentTool, shaping = toolFill, false
+			}
+		}
+
+		ctrl := rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl)
+
+		if shortcut(rl.KeySpace) {
+			if animation.playing {
+				animation.playing = false
+			} else {
+				animation.play(time.Now())
+			}
+		}
+
+		if shortcut(rl.KeyO) && !ctrl {
+			animation.onionSkin = !animation.onionSkin
+		}
+
+		// frames are stepped through with the arrows and moved along the timeline with Ctrl
+		if shortcut(rl.KeyLeft) || shortcut(rl.KeyRight) {
+			by := 1
+			if shortcut(rl.KeyLeft) {
+				by = -1
+			}
+			if ctrl {
+				showFrame(func() { animation.move(by) })
+			} else {
+				showFrame(func() { animation.step(by) })
+			}
+		}
+
+		if shortcut(rl.KeyDelete) {
+			showFrame(func() { animation.remove() })
+		}
+
+		// brackets shorten and lengthen the current frame by a frame at the target fps
+		tick := time.Second / time.Duration(fps)
+		if shortcut(rl.KeyLeftBracket) {
+			f := animation.frame()
+			f.duration = f.shownFor() - tick
+			if f.duration < tick {
+				f.duration = tick
+			}
+		}
+		if shortcut(rl.KeyRightBracket) {
+			f := animation.frame()
+			f.duration = f.shownFor() + tick
+		}
+
+		// R switches between square and round brushes, - and = make them smaller and bigger
+		if shortcut(rl.KeyR) {
+			brush = 1 - brush
+		}
+		if shortcut(rl.KeyMinus) && brushRadius > 0 {
+			brushRadius--
+		}
+		if shortcut(rl.KeyEqual) && brushRadius < maxBrushRadius {
+			brushRadius++
 		}
 
-		if rl.IsKeyPressed(rl.KeyC) {
+		if shortcut(rl.KeyC) {
+			edits.end(gridContents)
+			edits.begin()
+			edits.saveAll(gridContents)
 			for k, v := range gridContents {
 				v.Color = rl.Blank
 				gridContents[k] = v
 			}
+			edits.end(gridContents)
+		}
+
+		if ctrl {
+			if rl.IsKeyPressed(rl.KeyN) {
+				showFrame(animation.add)
+			}
+			if rl.IsKeyPressed(rl.KeyD) {
+				showFrame(animation.duplicate)
+			}
+			if rl.IsKeyPressed(rl.KeyE) {
+				showFrame(func() {})
+				if err := exportAnimation(exportName, animation, header, frameEncoder, maxBrightness/100); err != nil {
+					log.Error("exporting the animation: ", err)
+				} else {
+					log.Infof("exported %d frames, %v, to %s.gif and %s.data", len(animation.frames), animation.length(), exportName, exportName)
+				}
+			}
+			if rl.IsKeyPressed(rl.KeyZ) {
+				edits.undo(gridContents)
+			}
+			if rl.IsKeyPressed(rl.KeyY) {
+				edits.redo(gridContents)
+			}
+			if rl.IsKeyPressed(rl.KeyS) {
+				path := canvasPath()
+				if err := saveCanvas(path, canvasFromGrid(gridContents, uint16(numRows), uint16(numColumns))); err != nil {
+					log.Error("saving the canvas: ", err)
+				} else {
+					log.Info("saved the canvas to ", path)
+				}
+			}
+			if rl.IsKeyPressed(rl.KeyI) && importFile != "" {
+				importCanvas()
+			}
+			if rl.IsKeyPressed(rl.KeyO) {
+				if opened, err := openCanvas(canvasPath()); err != nil {
+					log.Error("opening the canvas: ", err)
+				} else {
+					edits.end(gridContents)
+					edits.begin()
+					edits.saveAll(gridContents)
+					if dropped := applyCanvas(gridContents, opened); dropped > 0 {
+						log.Warnf("%d squares of the %dx%d canvas are beyond the grid", dropped, opened.Rows, opened.Columns)
+					}
+					edits.end(gridContents)
+				}
+			}
+		}
+
+		// a stroke lasts while a button is held and is undone as one edit
+		if rl.IsMouseButtonDown(rl.MouseLeftButton) || rl.IsMouseButtonDown(rl.MouseRightButton) {
+			edits.begin()
+		} else {
+			edits.end(gridContents)
 		}
 
 		mousePos := rl.GetMousePosition()
 		gridCord, err := gridCordFromMouseCord(gridOrigin, mousePos)
 
-		if err == nil {
+		// shapes follow the mouse while the left button is held and are drawn when
+		// it is let go; the right button drops them
+		if shaping {
+			switch {
+			case rl.IsMouseButtonDown(rl.MouseRightButton):
+				shaping = false
+			case rl.IsMouseButtonDown(rl.MouseLeftButton):
+				if err == nil {
+					shapeTo = gridCord
+				}
+			default:
+				shaping = false
+				edits.begin()
+				colorSquares(edits, gridContents, currentTool.shapeCords(shapeFrom, shapeTo, filledShapes, uint16(numRows), uint16(numColumns)), drawColor, time.Now())
+				edits.end(gridContents)
+				picker.used()
+			}
+		} else if err == nil {
 			squareInfo, ok := gridContents[gridCord]
-			if ok {
-				if rl.IsMouseButtonDown(rl.MouseRightButton) {
-					squareInfo.Color = rl.Blank
-				} else if rl.IsMouseButtonDown(rl.MouseLeftButton) {
-					if floodFillMode {
-						floodFill(gridContents, squareInfo, drawColor)
-					}
-					squareInfo.Color = drawColor // might be redundant if we just filled it
-					squareInfo.CreatedAt = time.Now()
+			if !ok {
+				log.Fatal("not found", gridCord)
+			}
+			// freehand strokes join the square under the mouse to the one under it last frame
+			from := gridCord
+			if stroking {
+				from = strokeAt
+			}
+			if rl.IsMouseButtonDown(rl.MouseRightButton) {
+				colorSquares(edits, gridContents, strokeCords(from, gridCord, brush, brushRadius, uint16(numRows), uint16(numColumns)), rl.Blank, time.Time{})
+			} else if currentTool.dragged() && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+				shaping, shapeFrom, shapeTo = true, gridCord, gridCord
+			} else if currentTool == toolEyedropper && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+				if squareInfo.Color != rl.Blank {
+					picker.set(squareInfo.Color)
 				}
+				currentTool = toolBeforePicker
+			} else if rl.IsMouseButtonDown(rl.MouseLeftButton) && currentTool == toolFill {
+				// a fill can reach any square, so the grid is kept once as the fill starts;
+				// holding the button fills each region the mouse passes over as the same edit
+				if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+					picker.used()
+					edits.saveAll(gridContents)
+				}
+				floodFill(gridContents, squareInfo, drawColor)
+				squareInfo.Color = drawColor // might be redundant if we just filled it
+				squareInfo.CreatedAt = time.Now()
 				gridContents[squareInfo.GridCord] = squareInfo
-			} else {
-				log.Fatal("not found", gridCord)
+			} else if rl.IsMouseButtonDown(rl.MouseLeftButton) && currentTool == toolPencil {
+				if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+					picker.used()
+				}
+				colorSquares(edits, gridContents, strokeCords(from, gridCord, brush, brushRadius, uint16(numRows), uint16(numColumns)), drawColor, time.Now())
 			}
+			stroking, strokeAt = rl.IsMouseButtonDown(rl.MouseLeftButton) || rl.IsMouseButtonDown(rl.MouseRightButton), gridCord
+		} else {
+			stroking = false
 		}
 
 		rl.EndDrawing()
@@ -189,6 +543,36 @@ func paint(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// openStartCanvas opens the --open canvas, if it exists yet, and sizes the grid to it
+func openStartCanvas(cmd *cobra.Command) error {
+	if canvasFile == "" {
+		return nil
+	}
+	c, err := openCanvas(canvasFile)
+	if os.IsNotExist(err) {
+		log.Info("new canvas ", canvasFile, " will be created when saved")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("rows") || cmd.Flags().Changed("columns") {
+		if int32(c.Rows) != numRows || int32(c.Columns) != numColumns {
+			log.Warnf("using the %dx%d size of %s rather than %dx%d", c.Rows, c.Columns, canvasFile, numRows, numColumns)
+		}
+	}
+	numRows, numColumns = int32(c.Rows), int32(c.Columns)
+	startCanvas = &c
+	return nil
+}
+
+// canvasPath the file Ctrl+S and Ctrl+O save and open the canvas with
+func canvasPath() string {
+	if canvasFile == "" {
+		return "canvas.json"
+	}
+	return canvasFile
+}
+
 func drawGrid(gridOrigin rl.Vector2, numRows, numColumns int32) {
 	// draw row lines
 	for rowNum, rowBegin := int32(0), gridOrigin; rowNum <= numRows; rowNum++ {
@@ -204,31 +588,57 @@ func drawGrid(gridOrigin rl.Vector2, numRows, numColumns int32) {
 	}
 }
 
-func drawColorInputs(position rl.Vector2, red, green, blue *int) (rl.Color, rl.Vector2) {
+// drawColorInputs the controls that pick the color drawn with, from position
+// down; it returns the color and where the controls end
+func drawColorInputs(position rl.Vector2, picker *colorPicker) (rl.Color, rl.Vector2) {
 	position.X += 5
-	drawColorInput("red", red, position)
-	position.Y += 45
-	drawColorInput("green", green, position)
-	position.Y += 45
-	drawColorInput("blue", blue, position)
+	position = drawHSVPicker(position, picker)
+
+	c := picker.color
+	for i, channel := range []*uint8{&c.R, &c.G, &c.B} {
+		if v, changed := drawColorInput([]string{"R", "G", "B"}[i], &picker.text[i], rl.NewVector2(position.X+float32(35*i), position.Y)); changed {
+			*channel = v
+		}
+	}
 	position.Y += 45
 
-	color := makeColor(*red, *green, *blue, 255)
-	rl.DrawRectangleV(position, rl.NewVector2(spacingFloat, spacingFloat), color)
-	return color, rl.NewVector2(position.X, position.Y+spacingFloat)
+	// strokes are drawn with the alpha, which dims their LEDs
+	rg.Label(rl.NewRectangle(position.X, position.Y, pickerWidth, 20), fmt.Sprintf("Alpha %d", c.A))
+	position.Y += 20
+	c.A = uint8(rg.SliderBar(rl.NewRectangle(position.X, position.Y, pickerWidth, 20), float32(c.A), 0, maxRGB) + 0.5)
+	position.Y += 25
+	if c != picker.color {
+		picker.set(c)
+	}
+
+	position = drawHexInput(position, picker)
+	position = drawPalette(position, picker)
+	return picker.color, position
 }
 
-func drawColorInput(name string, colorValue *int, position rl.Vector2) {
-	rg.Label(rl.NewRectangle(position.X, position.Y, 50, 20), name)
-	color := rg.TextBox(rl.NewRectangle(position.X, position.Y+20, 50, 20), strconv.Itoa(*colorValue))
-	*colorValue, _ = strconv.Atoi(color)
-	if *colorValue > maxRGB {
-		*colorValue = maxRGB
+// drawColorInput a labelled box a channel is typed into.  It returns the channel,
+// clamped to 0 to 255, and whether it was changed to something that can be read;
+// what can't be read or was clamped is outlined and left until it is put right.
+func drawColorInput(name string, text *string, position rl.Vector2) (uint8, bool) {
+	rg.Label(rl.NewRectangle(position.X, position.Y, 30, 20), name)
+	box := rl.NewRectangle(position.X, position.Y+20, 30, 20)
+	typed := rg.TextBox(box, *text)
+	changed := typed != *text
+	*text = typed
+	v, err := parseChannel(typed)
+	if err != nil {
+		rl.DrawRectangleLinesEx(box, 1, rl.Red)
 	}
+	return v, changed && (err == nil || err == errClamped)
 }
 
-func makeColor(red, green, blue, alpha int) rl.Color {
-	return rl.NewColor(uint8(red), uint8(green), uint8(blue), uint8(alpha))
+// drawBrightnessSettings a slider for the brightness, in percent, LEDs are sent at
+func drawBrightnessSettings(position rl.Vector2, brightness *float32) rl.Vector2 {
+	rg.Label(rl.NewRectangle(position.X, position.Y, pickerWidth, 20), fmt.Sprintf("Brightness %.0f%%", *brightness))
+	position.Y += 20
+	*brightness = rg.SliderBar(rl.NewRectangle(position.X, position.Y, pickerWidth, 20), *brightness, 0, 100)
+	position.Y += 25
+	return position
 }
 
 func drawDecaySettings(position rl.Vector2, decayValue *bool) (bool, rl.Vector2) {
@@ -238,60 +648,79 @@ func drawDecaySettings(position rl.Vector2, decayValue *bool) (bool, rl.Vector2)
 	return *decayValue, position
 }
 
+// drawOnionSkin draws the squares of c faintly, to draw the next frame over
+func drawOnionSkin(gridContents map[GridCord]SquareInfo, c canvas) {
+	for _, square := range c.Squares {
+		if under, ok := gridContents[square.GridCord]; ok {
+			rl.DrawRectangleV(under.Origin, rl.NewVector2(spacingFloat, spacingFloat), rl.Fade(square.Color, 0.3))
+		}
+	}
+}
+
 func drawSquares(gridContents map[GridCord]SquareInfo, fadeMode, decayMode bool) {
 	for cord, square := range gridContents {
 		color := fadeAndDecay(square, fadeMode, decayMode)
-		square.Color = color
-		gridContents[cord] = square
+		if color == rl.Blank {
+			// decayed squares are cleared; fading ones keep their alpha to fade from
+			square.Color = color
+			gridContents[cord] = square
+		}
 		rl.DrawRectangleV(square.Origin, rl.NewVector2(spacingFloat, spacingFloat), color)
 	}
 }
 
-func exportSquares(file *os.File, squares map[GridCord]SquareInfo, fadeMode, decayMode bool) {
-	ledInfo := frame.LEDInfo{}
-	var binBuf bytes.Buffer
+// exportSquares encodes the squares as a frame, at brightness from 0 to 1
+func exportSquares(encoder *frame.DeltaEncoder, timestamp time.Duration, squares map[GridCord]SquareInfo, fadeMode, decayMode bool, brightness float32) {
+	// frames are only sent when the display changes, so each is shown until the next
+	ledFrame := frame.Frame{
+		Header: frame.Header{
+			Timestamp: uint32(timestamp / time.Millisecond),
+		},
+		LEDs: make([]frame.LEDInfo, 0, len(squares)),
+	}
 	for _, square := range squares {
-		color := fadeAndDecay(square, fadeMode, decayMode)
-
-		ledInfo.Column = square.GridCord.Column
-		ledInfo.Row = square.GridCord.Row
-		ledInfo.Brightness = color.A
-		ledInfo.Red = square.Color.R
-		ledInfo.Blue = square.Color.B
-		ledInfo.Green = square.Color.G
-
-		err := binary.Write(&binBuf, binary.BigEndian, ledInfo)
-		if err != nil {
-			panic(err)
-		}
-		_, err = file.Write(binBuf.Bytes())
-		if err != nil {
-			log.Println(binBuf)
-			panic(err)
-		}
+		fade := fadeFactor(square, fadeMode, decayMode)
+		ledFrame.LEDs = append(ledFrame.LEDs, ledFromSquare(square.GridCord, square.Color, fade, brightness))
+	}
+	// outputs that fail drop their frames, so only the binary log gets here
+	if err := encoder.Encode(ledFrame); err != nil {
+		log.Fatal("writing the binary log: ", err)
 	}
 }
 
+// fadeAndDecay the color a square is drawn in: its own, with its alpha scaled
+// by fadeFactor, or blank once it has decayed
 func fadeAndDecay(square SquareInfo, fadeMode, decayMode bool) rl.Color {
-	if decayMode {
-		if timeLeft := time.Now().Sub(square.CreatedAt); timeLeft < decayTime {
-			// scale for alpha
-			var alpha = float32(1.0)
-			if fadeMode {
-				alpha = 1.0 - float32(timeLeft.Nanoseconds())/float32(decayTime.Nanoseconds())
-			}
-			return rl.Fade(square.Color, alpha)
-		}
+	fade := fadeFactor(square, fadeMode, decayMode)
+	if fade == 0 {
 		return rl.Blank
 	}
-	return square.Color
+	color := square.Color
+	color.A = uint8(float32(color.A)*fade + 0.5)
+	return color
+}
+
+// fadeFactor how much of a square's alpha is left as it decays: 1 when it is
+// drawn, falling to 0 in fade mode, and 0 once it has decayed
+func fadeFactor(square SquareInfo, fadeMode, decayMode bool) float32 {
+	if !decayMode {
+		return 1
+	}
+	timeLeft := time.Now().Sub(square.CreatedAt)
+	if timeLeft >= decayTime {
+		return 0
+	}
+	if fadeMode {
+		return 1.0 - float32(timeLeft.Nanoseconds())/float32(decayTime.Nanoseconds())
+	}
+	return 1
 }
 
-func makeGridContents(gridOrigin rl.Vector2, numRows, numColumns uint8) map[GridCord]SquareInfo {
+func makeGridContents(gridOrigin rl.Vector2, numRows, numColumns uint16) map[GridCord]SquareInfo {
 	gridContents := make(map[GridCord]SquareInfo)
 
-	for r := uint8(0); r < numRows; r++ {
-		for c := uint8(0); c < numColumns; c++ {
+	for r := uint16(0); r < numRows; r++ {
+		for c := uint16(0); c < numColumns; c++ {
 			squareInfo := makeSquare(gridOrigin, r, c)
 			gridContents[squareInfo.GridCord] = squareInfo
 		}
@@ -299,7 +728,7 @@ func makeGridContents(gridOrigin rl.Vector2, numRows, numColumns uint8) map[Grid
 	return gridContents
 }
 
-func makeSquare(gridOrigin rl.Vector2, row, column uint8) SquareInfo {
+func makeSquare(gridOrigin rl.Vector2, row, column uint16) SquareInfo {
 	colInt32, rowInt32 := int32(column), int32(row)
 
 	return SquareInfo{
@@ -319,7 +748,7 @@ func gridCordFromMouseCord(gridOrigin, mouseVec rl.Vector2) (GridCord, error) {
 	xPos := x / spacing
 	yPos := y / spacing
 
-	return GridCord{Row: uint8(yPos), Column: uint8(xPos)}, nil
+	return GridCord{Row: uint16(yPos), Column: uint16(xPos)}, nil
 }
 
 /*
@@ -354,8 +783,8 @@ func floodFill(gridContents map[GridCord]SquareInfo, square SquareInfo, newColor
 		west, east := queue[i], queue[i]
 
 		// Go West
-		west = furthestSquare(gridContents, west, targetColor, func(a, b uint8) uint8 { return a - b })
-		east = furthestSquare(gridContents, east, targetColor, func(a, b uint8) uint8 { return a + b })
+		west = furthestSquare(gridContents, west, targetColor, func(a, b uint16) uint16 { return a - b })
+		east = furthestSquare(gridContents, east, targetColor, func(a, b uint16) uint16 { return a + b })
 
 		// set nodes in between to newColor
 		for wCol, eCol := west.GridCord.Column, east.GridCord.Column; wCol <= eCol; wCol++ {
@@ -379,7 +808,7 @@ func floodFill(gridContents map[GridCord]SquareInfo, square SquareInfo, newColor
 				}
 			}
 			// check to the south
-			if currentCord.Row < uint8(numRows)-1 {
+			if currentCord.Row < uint16(numRows)-1 {
 				southCord := currentCord
 				southCord.Row++
 				southSquare, ok := gridContents[southCord]
@@ -393,7 +822,7 @@ func floodFill(gridContents map[GridCord]SquareInfo, square SquareInfo, newColor
 	return squaresChanged
 }
 
-func furthestSquare(gridContents map[GridCord]SquareInfo, startingPoint SquareInfo, targetColor rl.Color, f func(a, b uint8) uint8) SquareInfo {
+func furthestSquare(gridContents map[GridCord]SquareInfo, startingPoint SquareInfo, targetColor rl.Color, f func(a, b uint16) uint16) SquareInfo {
 	result := startingPoint
 	//fmt.Printf("Started at: %+v for target color: %+v\n", startingPoint, targetColor)
 	for {
@@ -21,9 +21,9 @@ import (
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-func makeGridLine(numColumns uint8) map[GridCord]SquareInfo {
+func makeGridLine(numColumns uint16) map[GridCord]SquareInfo {
 	result := make(map[GridCord]SquareInfo)
-	for c := uint8(0); c < numColumns; c++ {
+	for c := uint16(0); c < numColumns; c++ {
 		cord := GridCord{Column: c, Row: 0}
 		result[cord] = SquareInfo{Color: rl.Black, GridCord: cord}
 	}
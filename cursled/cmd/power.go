@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// powerConfig the power section of the config file, in milliamps, e.g.
+//
+//	power:
+//	  red: 20
+//	  green: 20
+//	  blue: 20
+//	  idle: 1
+//	  budget: 4000
+type powerConfig struct {
+	Red    float64 `mapstructure:"red"`
+	Green  float64 `mapstructure:"green"`
+	Blue   float64 `mapstructure:"blue"`
+	Idle   float64 `mapstructure:"idle"`
+	Budget float64 `mapstructure:"budget"`
+}
+
+func init() {
+	viper.SetDefault("power.red", frame.DefaultChannelMilliamps)
+	viper.SetDefault("power.green", frame.DefaultChannelMilliamps)
+	viper.SetDefault("power.blue", frame.DefaultChannelMilliamps)
+}
+
+// addPowerFlags adds the flag giving the power budget in place of the config file's
+func addPowerFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64("powerBudget", 0, "milliamps the LED supply can give; 0 for no limit")
+}
+
+// loadPowerLimit builds the power model frames are limited with from the flag
+// added with addPowerFlags or the config file; nil when no budget is set
+func loadPowerLimit(cmd *cobra.Command) (*frame.PowerLimit, error) {
+	viper.BindPFlag("power.budget", cmd.Flags().Lookup("powerBudget"))
+	config := powerConfig{}
+	if err := viper.UnmarshalKey("power", &config); err != nil {
+		return nil, err
+	}
+	if config.Budget <= 0 {
+		return nil, nil
+	}
+	if config.Red < 0 || config.Green < 0 || config.Blue < 0 || config.Idle < 0 {
+		return nil, fmt.Errorf("power draw can't be negative: %+v", config)
+	}
+	return &frame.PowerLimit{
+		Red:    config.Red,
+		Green:  config.Green,
+		Blue:   config.Blue,
+		Idle:   config.Idle,
+		Budget: config.Budget,
+	}, nil
+}
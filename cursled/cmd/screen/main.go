@@ -2,106 +2,91 @@ package main
 
 import (
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"strings"
 
+	"github.com/aaronbush/go-stuff/cursled/frame"
 	"github.com/gookit/color"
 )
 
-const StartSentinel uint32 = 0xDEADBEEF
-
-type Header struct {
-	NumLEDs uint16
-}
-
 type ScreenKey struct {
-	Row    uint8
-	Column uint8
-}
-
-type LEDInfo struct {
-	Row        uint8
-	Column     uint8
-	Red        uint8
-	Green      uint8
-	Blue       uint8
-	Brightness uint8
+	Row    uint16
+	Column uint16
 }
 
 func main() {
-	maxLeds := uint16(1000)
-	maxRows := uint8(40)
-	numRows := uint8(0)
-	maxColumns := uint8(20)
-	numColumns := uint8(0)
-
-	// i, _ := os.Open(os.Args[1])
-	// defer i.Close()
-
-	b := makeLedsData()
-	i := bytes.NewReader(b)
-
-	ready := false
-	dataIn := make([]byte, 4)
+	maxLeds := 1000
+	maxRows := uint16(40)
+	numRows := uint16(0)
+	maxColumns := uint16(20)
+	numColumns := uint16(0)
+
+	// frames from the file named, e.g. paint's binary log, or some made up ones
+	var r io.Reader = bytes.NewReader(makeLedsData())
+	if len(os.Args) > 1 {
+		file, err := os.Open(os.Args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		r = file
+	}
+	decoder := frame.NewDecoder(r)
+	decoder.MaxLEDs = maxLeds
+	state := frame.NewState()
 
 	for {
-		if !ready {
-			_, err := i.Read(dataIn)
-			if err == io.EOF {
-				return
-			} else if err != nil {
-				log.Fatal("error reading for start sentinel", err)
-			}
-			if binary.BigEndian.Uint32(dataIn) == StartSentinel {
-				ready = true
-			}
+		ledFrame, err := decoder.Decode()
+		if err == io.EOF {
+			log.Printf("%+v\n", decoder.Stats())
+			return
 		}
-
-		// we are ready...
-		header := Header{}
-
-		if err := binary.Read(i, binary.BigEndian, &header); err != nil {
-			log.Fatal("error reading in the header", err)
+		switch err.(type) {
+		case nil:
+		case *frame.OversizeError, *frame.UnsupportedVersionError, *frame.UnsupportedEncodingError, *frame.UnsupportedPixelFormatError:
+			log.Println("discarding frame", err)
+			continue
+		case *frame.TruncatedError:
+			log.Println("stream ended mid-frame", err)
 			return
+		default:
+			log.Fatal("error reading in the frame", err)
 		}
-		header.NumLEDs = min(header.NumLEDs, maxLeds)
 
-		ledInfo := LEDInfo{}
-		log.Printf("Reading in info for %d LEDs\n", header.NumLEDs)
+		log.Printf("Reading in info for %d LEDs (key frame: %t)\n", ledFrame.Header.NumLEDs, ledFrame.Header.IsKeyFrame())
+		state.Apply(ledFrame)
+		if !state.Synced() {
+			log.Println("waiting for a key frame")
+			continue
+		}
 
-		ledInfos := make(map[ScreenKey]LEDInfo)
+		ledInfos := make(map[ScreenKey]frame.LEDInfo)
 
-		for n := uint16(0); n < header.NumLEDs; n++ {
-			log.Println("reading in info for led #", n)
-			if err := binary.Read(i, binary.BigEndian, &ledInfo); err != nil {
-				log.Fatal("error reading in the led info", err)
-				return
-			}
+		for _, ledInfo := range state.LEDs() {
 			if ledInfo.Column > maxColumns || ledInfo.Row > maxRows {
 				continue // discard out of range leds
 			}
 			ledInfos[ScreenKey{ledInfo.Row, ledInfo.Column}] = ledInfo
 
-			numColumns = max8(ledInfo.Column, numColumns)
-			numRows = max8(ledInfo.Row, numRows)
+			numColumns = max(ledInfo.Column, numColumns)
+			numRows = max(ledInfo.Row, numRows)
 		}
-		numColumns = min8(numColumns, maxColumns)
-		numRows = min8(numRows, maxRows)
+		numColumns = min(numColumns, maxColumns)
+		numRows = min(numRows, maxRows)
 		drawTable(ledInfos, numRows, numColumns)
-		ready = false
 	}
 }
 
-func drawTable(leds map[ScreenKey]LEDInfo, numRows uint8, numColumns uint8) {
+func drawTable(leds map[ScreenKey]frame.LEDInfo, numRows uint16, numColumns uint16) {
 	log.Printf("%dx%d -> %v\n", numRows, numColumns, leds)
 	black := color.BgBlack.Sprint("  ")
-	for row := uint8(1); row <= numRows; row++ {
+	for row := uint16(0); row <= numRows; row++ {
 		fmt.Printf("%02d:", row)
 		var sb strings.Builder
-		for column := uint8(1); column <= numColumns; column++ {
+		for column := uint16(0); column <= numColumns; column++ {
 			if led, ok := leds[ScreenKey{row, column}]; ok {
 				c := color.RGB(led.Red, led.Green, led.Blue, true)
 				sb.WriteString(c.Sprintf("  "))
@@ -121,9 +106,6 @@ func max(x, y uint16) uint16 {
 	}
 	return y
 }
-func max8(x, y uint8) uint8 {
-	return uint8(max(uint16(x), uint16(y)))
-}
 
 func min(x, y uint16) uint16 {
 	if max(x, y) == x {
@@ -132,11 +114,7 @@ func min(x, y uint16) uint16 {
 	return x
 }
 
-func min8(x, y uint8) uint8 {
-	return uint8(min(uint16(x), uint16(y)))
-}
-
-func filter(leds []LEDInfo, test func(LEDInfo) bool) (ret []LEDInfo) {
+func filter(leds []frame.LEDInfo, test func(frame.LEDInfo) bool) (ret []frame.LEDInfo) {
 	for _, l := range leds {
 		if test(l) {
 			ret = append(ret, l)
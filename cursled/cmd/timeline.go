@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	rg "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// defaultFrameDuration how long frames without a duration of their own are
+// shown for, as browsers do for GIFs
+const defaultFrameDuration = 100 * time.Millisecond
+
+// the frame strip drawn under the status bar: a thumbnail and duration for each
+// frame above a row of buttons to edit the timeline
+const (
+	thumbnailSize  = 40
+	thumbnailGap   = 4
+	timelineHeight = thumbnailSize + 14 + 26
+)
+
+// timelineFrame one frame of an animation on the canvas, with the edits made
+// to it for undo
+type timelineFrame struct {
+	canvas   canvas
+	duration time.Duration
+	edits    *history
+}
+
+// timeline the frames of an animation drawn on the canvas one at a time.  The
+// canvas holds the current frame while it is drawn on; it is stored back into
+// the frame before another is shown.
+type timeline struct {
+	frames    []*timelineFrame
+	current   int
+	playing   bool
+	shownAt   time.Time // when the current frame was put on the canvas
+	undoLimit int
+	onionSkin bool // show the previous frame under the canvas
+}
+
+// newTimeline a timeline of the single frame first
+func newTimeline(first canvas, duration time.Duration, undoLimit int) *timeline {
+	t := &timeline{undoLimit: undoLimit}
+	t.frames = []*timelineFrame{t.newFrame(first, duration)}
+	return t
+}
+
+func (t *timeline) newFrame(c canvas, duration time.Duration) *timelineFrame {
+	return &timelineFrame{canvas: c, duration: duration, edits: newHistory(t.undoLimit)}
+}
+
+// frame the frame on the canvas
+func (t *timeline) frame() *timelineFrame {
+	return t.frames[t.current]
+}
+
+// previous the frame before the one on the canvas, for onion skinning
+func (t *timeline) previous() (*timelineFrame, bool) {
+	if t.current == 0 {
+		return nil, false
+	}
+	return t.frames[t.current-1], true
+}
+
+// insert puts frames after the current one and makes the first of them current
+func (t *timeline) insert(frames ...*timelineFrame) {
+	if len(frames) == 0 {
+		return
+	}
+	for _, f := range frames {
+		if f.edits == nil {
+			f.edits = newHistory(t.undoLimit)
+		}
+	}
+	at := t.current + 1
+	t.frames = append(t.frames[:at], append(frames, t.frames[at:]...)...)
+	t.current = at
+}
+
+// add puts a blank frame, as long as the current one, after it
+func (t *timeline) add() {
+	blank := canvas{Rows: t.frame().canvas.Rows, Columns: t.frame().canvas.Columns}
+	t.insert(t.newFrame(blank, t.frame().duration))
+}
+
+// duplicate puts a copy of the current frame after it
+func (t *timeline) duplicate() {
+	c := t.frame().canvas
+	c.Squares = append([]SquareInfo(nil), c.Squares...)
+	t.insert(t.newFrame(c, t.frame().duration))
+}
+
+// remove deletes the current frame, showing the one before it; the last frame
+// can't be removed
+func (t *timeline) remove() bool {
+	if len(t.frames) < 2 {
+		return false
+	}
+	t.frames = append(t.frames[:t.current], t.frames[t.current+1:]...)
+	if t.current > 0 {
+		t.current--
+	}
+	return true
+}
+
+// move shifts the current frame by places along the timeline, keeping it current
+func (t *timeline) move(by int) bool {
+	to := t.current + by
+	if to < 0 || to >= len(t.frames) || by == 0 {
+		return false
+	}
+	moving := t.frames[t.current]
+	if by > 0 {
+		copy(t.frames[t.current:], t.frames[t.current+1:to+1])
+	} else {
+		copy(t.frames[to+1:], t.frames[to:t.current])
+	}
+	t.frames[to], t.current = moving, to
+	return true
+}
+
+// step makes the frame by places along current, wrapping around at the ends
+func (t *timeline) step(by int) {
+	n := len(t.frames)
+	t.current = ((t.current+by)%n + n) % n
+}
+
+// length the time the whole animation takes
+func (t *timeline) length() time.Duration {
+	var total time.Duration
+	for _, f := range t.frames {
+		total += f.shownFor()
+	}
+	return total
+}
+
+// shownFor how long the frame is shown during playback
+func (f *timelineFrame) shownFor() time.Duration {
+	if f.duration <= 0 {
+		return defaultFrameDuration
+	}
+	return f.duration
+}
+
+// play starts showing the frames in turn, from the current one, at now
+func (t *timeline) play(now time.Time) {
+	t.playing, t.shownAt = len(t.frames) > 1, now
+}
+
+// due reports whether the current frame's time is up at now during playback,
+// and if so starts timing the frame after it
+func (t *timeline) due(now time.Time) bool {
+	if !t.playing {
+		return false
+	}
+	duration := t.frame().shownFor()
+	if now.Sub(t.shownAt) < duration {
+		return false
+	}
+	// keep to the frame durations even when the window is drawn late
+	if t.shownAt = t.shownAt.Add(duration); now.Sub(t.shownAt) > duration {
+		t.shownAt = now
+	}
+	return true
+}
+
+// firstShown the first frame in a strip of cells frames, scrolled so the current
+// one is as near the middle as the ends of the timeline allow
+func (t *timeline) firstShown(cells int) int {
+	first := t.current - cells/2
+	if last := len(t.frames) - cells; first > last {
+		first = last
+	}
+	if first < 0 {
+		first = 0
+	}
+	return first
+}
+
+// drawTimeline the frame strip across width from position, with current drawn
+// in place of the current frame while it is on the canvas; it returns the change
+// a click makes to the timeline, for showFrame, or nil
+func drawTimeline(position rl.Vector2, width float32, t *timeline, current canvas) func() {
+	var change func()
+	mouse := rl.GetMousePosition()
+	cells := int(width) / (thumbnailSize + thumbnailGap)
+	first := t.firstShown(cells)
+	for i := first; i < len(t.frames) && i < first+cells; i++ {
+		f, c := t.frames[i], t.frames[i].canvas
+		if i == t.current {
+			c = current
+		}
+		cell := rl.NewRectangle(position.X+float32((i-first)*(thumbnailSize+thumbnailGap)), position.Y, thumbnailSize, thumbnailSize)
+		drawThumbnail(cell, c)
+		outline := rl.DarkGray
+		if i == t.current {
+			outline = rl.Red
+		}
+		rl.DrawRectangleLinesEx(cell, 1, outline)
+		rl.DrawText(fmt.Sprintf("%dms", f.shownFor().Milliseconds()), int32(cell.X), int32(cell.Y+thumbnailSize+2), 10, rl.Gray)
+		if i != t.current && rl.CheckCollisionPointRec(mouse, cell) && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+			by := i - t.current
+			change = func() { t.step(by) }
+		}
+	}
+
+	position.Y += thumbnailSize + 16
+	buttons := []struct {
+		text   string
+		change func()
+	}{
+		{"add", t.add},
+		{"dup", t.duplicate},
+		{"delete", func() { t.remove() }},
+		{"<", func() { t.move(-1) }},
+		{">", func() { t.move(1) }},
+	}
+	for _, b := range buttons {
+		if rg.Button(rl.NewRectangle(position.X, position.Y, 50, 20), b.text) {
+			change = b.change
+		}
+		position.X += 52
+	}
+	return change
+}
+
+// drawThumbnail draws c scaled down to fit in bounds
+func drawThumbnail(bounds rl.Rectangle, c canvas) {
+	rl.DrawRectangleRec(bounds, rl.Black)
+	longest := c.Rows
+	if c.Columns > longest {
+		longest = c.Columns
+	}
+	if longest == 0 {
+		return
+	}
+	scale := bounds.Width / float32(longest)
+	for _, square := range c.Squares {
+		origin := rl.NewVector2(bounds.X+float32(square.GridCord.Column)*scale, bounds.Y+float32(square.GridCord.Row)*scale)
+		rl.DrawRectangleV(origin, rl.NewVector2(scale, scale), square.Color)
+	}
+}
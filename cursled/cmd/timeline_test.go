@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+// framesOfRows a timeline whose frames are told apart by their number of rows
+func framesOfRows(rows ...uint16) *timeline {
+	tl := newTimeline(canvas{Rows: rows[0]}, 0, 10)
+	for _, r := range rows[1:] {
+		tl.current = len(tl.frames) - 1
+		tl.insert(tl.newFrame(canvas{Rows: r}, 0))
+	}
+	tl.current = 0
+	return tl
+}
+
+func rowsOf(tl *timeline) []uint16 {
+	rows := []uint16{}
+	for _, f := range tl.frames {
+		rows = append(rows, f.canvas.Rows)
+	}
+	return rows
+}
+
+func sameRows(t *testing.T, name string, tl *timeline, current int, want ...uint16) {
+	t.Helper()
+	got := rowsOf(tl)
+	if len(got) != len(want) || tl.current != current {
+		t.Fatalf("%s: frames %v at %d, want %v at %d", name, got, tl.current, want, current)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s: frames %v at %d, want %v at %d", name, got, tl.current, want, current)
+		}
+	}
+}
+
+func TestTimelinePlayback(t *testing.T) {
+	start := time.Now()
+	tl := framesOfRows(1, 2, 3)
+	tl.frames[0].duration = 50 * time.Millisecond // frame 2 is shown for defaultFrameDuration
+	tl.frames[2].duration = 20 * time.Millisecond
+	if tl.due(start.Add(time.Hour)) {
+		t.Fatal("moved on before playing")
+	}
+	tl.play(start)
+	steps := []struct {
+		at   time.Duration
+		rows uint16 // of the frame moved on to; 0 for none
+	}{
+		{40 * time.Millisecond, 0},
+		{50 * time.Millisecond, 2},
+		{100 * time.Millisecond, 0},
+		{150 * time.Millisecond, 3},
+		{170 * time.Millisecond, 1}, // back to the start
+	}
+	for _, step := range steps {
+		due := tl.due(start.Add(step.at))
+		if due {
+			tl.step(1)
+		}
+		if due != (step.rows != 0) || (due && tl.frame().canvas.Rows != step.rows) {
+			t.Errorf("at %v moved on %t to frame of %d rows, want %d", step.at, due, tl.frame().canvas.Rows, step.rows)
+		}
+	}
+	if tl.length() != 170*time.Millisecond {
+		t.Errorf("length %v, want 170ms", tl.length())
+	}
+
+	still := newTimeline(canvas{}, 0, 10)
+	if still.play(start); still.playing {
+		t.Error("a single frame is played")
+	}
+}
+
+func TestTimelineEditing(t *testing.T) {
+	tl := framesOfRows(1, 2, 3)
+	tl.step(-1)
+	sameRows(t, "step back wraps", tl, 2, 1, 2, 3)
+
+	tl.move(-2)
+	sameRows(t, "move to the start", tl, 0, 3, 1, 2)
+	if tl.move(-1) {
+		t.Error("moved before the first frame")
+	}
+
+	tl.frames[0].canvas.Squares = []SquareInfo{{GridCord: GridCord{Column: 1}}}
+	tl.duplicate()
+	sameRows(t, "duplicate", tl, 1, 3, 3, 1, 2)
+	tl.frame().canvas.Squares[0].GridCord.Column = 2
+	if tl.frames[0].canvas.Squares[0].GridCord.Column != 1 {
+		t.Error("a duplicate shares its squares with the original")
+	}
+	if tl.frame().edits == tl.frames[0].edits {
+		t.Error("a duplicate shares its undo history with the original")
+	}
+
+	tl.add()
+	sameRows(t, "add", tl, 2, 3, 3, 3, 1, 2)
+	if len(tl.frame().canvas.Squares) != 0 {
+		t.Error("an added frame isn't blank")
+	}
+
+	tl.insert(&timelineFrame{canvas: canvas{Rows: 7}}, &timelineFrame{canvas: canvas{Rows: 8}})
+	sameRows(t, "insert", tl, 3, 3, 3, 3, 7, 8, 1, 2)
+	if tl.frame().edits == nil {
+		t.Error("an inserted frame has no undo history")
+	}
+
+	tl.move(2)
+	sameRows(t, "move later", tl, 5, 3, 3, 3, 8, 1, 7, 2)
+
+	for tl.remove() {
+	}
+	sameRows(t, "remove all but the last", tl, 0, 2)
+	if previous, ok := tl.previous(); ok {
+		t.Errorf("the only frame has %v before it", previous)
+	}
+}
+
+func TestTimelineFirstShown(t *testing.T) {
+	tests := []struct {
+		name                   string
+		frames, current, cells int
+		want                   int
+	}{
+		{"all fit", 3, 2, 5, 0},
+		{"start", 10, 1, 4, 0},
+		{"middle", 10, 5, 4, 3},
+		{"end", 10, 9, 4, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := framesOfRows(make([]uint16, tt.frames)...)
+			tl.current = tt.current
+			if got := tl.firstShown(tt.cells); got != tt.want {
+				t.Errorf("firstShown(%d) = %d, want %d", tt.cells, got, tt.want)
+			}
+		})
+	}
+}
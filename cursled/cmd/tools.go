@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"image"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/raster"
+	rg "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// tool what the left mouse button does on the grid
+type tool int
+
+const (
+	toolPencil tool = iota
+	toolFill
+	toolLine
+	toolRectangle
+	toolEllipse
+	toolEyedropper // loads the color of a square into the color inputs
+)
+
+var toolNames = []string{"pencil", "fill", "line", "rect", "ellipse", "picker"}
+
+func (t tool) String() string {
+	return toolNames[t]
+}
+
+// brushShape the squares around the mouse a freehand stroke colors
+type brushShape int
+
+const (
+	brushSquare brushShape = iota
+	brushRound
+)
+
+// maxBrushRadius the widest brush, 2*maxBrushRadius+1 squares across
+const maxBrushRadius = 10
+
+func (b brushShape) String() string {
+	if b == brushRound {
+		return "round"
+	}
+	return "square"
+}
+
+// brushCords the squares a brush of radius covers around center, leaving out
+// any beyond a rows x columns grid
+func brushCords(center GridCord, shape brushShape, radius int, rows, columns uint16) []GridCord {
+	c := image.Pt(int(center.Column), int(center.Row))
+	if shape == brushRound {
+		return gridCords(raster.Disc(c, radius), rows, columns)
+	}
+	corner := image.Pt(radius, radius)
+	return gridCords(raster.Rect(c.Sub(corner), c.Add(corner), true), rows, columns)
+}
+
+// strokeCords the squares a brush covers as it moves in a straight line from
+// one square to another, so fast strokes leave no gaps between frames
+func strokeCords(from, to GridCord, shape brushShape, radius int, rows, columns uint16) []GridCord {
+	var cords []GridCord
+	seen := map[GridCord]bool{}
+	for _, center := range toolLine.shapeCords(from, to, false, rows, columns) {
+		for _, cord := range brushCords(center, shape, radius, rows, columns) {
+			if !seen[cord] {
+				seen[cord] = true
+				cords = append(cords, cord)
+			}
+		}
+	}
+	return cords
+}
+
+// colorSquares colors the squares at cords as part of the edit being made
+func colorSquares(edits *history, gridContents map[GridCord]SquareInfo, cords []GridCord, color rl.Color, at time.Time) {
+	for _, cord := range cords {
+		square, ok := gridContents[cord]
+		if !ok {
+			continue
+		}
+		edits.save(square)
+		square.Color, square.CreatedAt = color, at
+		gridContents[cord] = square
+	}
+}
+
+// dragged reports whether t draws a shape from where the button is pressed to
+// where it is let go
+func (t tool) dragged() bool {
+	return t == toolLine || t == toolRectangle || t == toolEllipse
+}
+
+// shapeCords the squares of t's shape from one end or corner to the other,
+// leaving out any beyond a rows x columns grid
+func (t tool) shapeCords(from, to GridCord, filled bool, rows, columns uint16) []GridCord {
+	a := image.Pt(int(from.Column), int(from.Row))
+	b := image.Pt(int(to.Column), int(to.Row))
+	var points []image.Point
+	switch t {
+	case toolLine:
+		points = raster.Line(a, b)
+	case toolRectangle:
+		points = raster.Rect(a, b, filled)
+	case toolEllipse:
+		points = raster.Ellipse(a, b, filled)
+	}
+	return gridCords(points, rows, columns)
+}
+
+// gridCords the squares at points, leaving out any beyond a rows x columns grid
+func gridCords(points []image.Point, rows, columns uint16) []GridCord {
+	cords := make([]GridCord, 0, len(points))
+	for _, p := range points {
+		if p.X >= 0 && p.Y >= 0 && p.X < int(columns) && p.Y < int(rows) {
+			cords = append(cords, GridCord{Row: uint16(p.Y), Column: uint16(p.X)})
+		}
+	}
+	return cords
+}
+
+// drawToolPalette a button for each tool, whether shapes are filled and the
+// brush freehand strokes are drawn with; it returns the tool picked and where
+// the controls end
+func drawToolPalette(position rl.Vector2, current tool, filled *bool, shape *brushShape, radius *int) (tool, rl.Vector2) {
+	position.Y += 25
+	rg.Label(rl.NewRectangle(position.X, position.Y, 50, 20), "Tools")
+	position.Y += 20
+	picked := current
+	for i := range toolNames {
+		if rg.ToggleButton(rl.NewRectangle(position.X, position.Y, 50, 20), toolNames[i], current == tool(i)) && current != tool(i) {
+			picked = tool(i)
+		}
+		position.Y += 22
+	}
+	rg.Label(rl.NewRectangle(position.X, position.Y, 50, 20), "Filled")
+	position.Y += 20
+	*filled = rg.CheckBox(rl.NewRectangle(position.X, position.Y, 50, 20), *filled)
+	position.Y += 25
+	rg.Label(rl.NewRectangle(position.X, position.Y, 50, 20), "Brush")
+	position.Y += 20
+	round := *shape == brushRound
+	if rg.ToggleButton(rl.NewRectangle(position.X, position.Y, 50, 20), shape.String(), round) != round {
+		*shape = 1 - *shape
+	}
+	position.Y += 22
+	*radius = rg.Spinner(rl.NewRectangle(position.X, position.Y, 50, 20), *radius, 0, maxBrushRadius)
+	return picked, position
+}
+
+// drawShapePreview draws the squares a shape would color while it is dragged out
+func drawShapePreview(gridContents map[GridCord]SquareInfo, cords []GridCord, color rl.Color) {
+	for _, cord := range cords {
+		rl.DrawRectangleV(gridContents[cord].Origin, rl.NewVector2(spacingFloat, spacingFloat), rl.Fade(color, 0.6))
+	}
+}
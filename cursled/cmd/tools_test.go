@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestShapeCords(t *testing.T) {
+	line := toolLine.shapeCords(GridCord{Row: 0, Column: 0}, GridCord{Row: 1, Column: 3}, false, 4, 4)
+	want := []GridCord{{Row: 0, Column: 0}, {Row: 0, Column: 1}, {Row: 1, Column: 2}, {Row: 1, Column: 3}}
+	if !reflect.DeepEqual(line, want) {
+		t.Errorf("line = %v, want %v", line, want)
+	}
+
+	if outline := toolRectangle.shapeCords(GridCord{}, GridCord{Row: 2, Column: 2}, false, 3, 3); len(outline) != 8 {
+		t.Errorf("outline of a 3x3 rectangle has %d squares, want 8", len(outline))
+	}
+	if filled := toolRectangle.shapeCords(GridCord{}, GridCord{Row: 2, Column: 2}, true, 2, 3); len(filled) != 6 {
+		t.Errorf("3x3 rectangle on a 2x3 grid colors %d squares, want 6", len(filled))
+	}
+	if none := toolPencil.shapeCords(GridCord{}, GridCord{Row: 2, Column: 2}, true, 3, 3); len(none) != 0 {
+		t.Errorf("pencil drew the shape %v", none)
+	}
+}
+
+func TestStrokeCords(t *testing.T) {
+	// a jump of four squares in one frame leaves no gap
+	gap := strokeCords(GridCord{Row: 1, Column: 0}, GridCord{Row: 1, Column: 4}, brushSquare, 0, 3, 5)
+	want := []GridCord{{Row: 1, Column: 0}, {Row: 1, Column: 1}, {Row: 1, Column: 2}, {Row: 1, Column: 3}, {Row: 1, Column: 4}}
+	if !reflect.DeepEqual(gap, want) {
+		t.Errorf("stroke = %v, want %v", gap, want)
+	}
+
+	// a wide brush covers each square once, and stops at the edges of the grid
+	wide := strokeCords(GridCord{Row: 1, Column: 0}, GridCord{Row: 1, Column: 4}, brushSquare, 1, 3, 5)
+	if len(wide) != 15 {
+		t.Errorf("3 wide stroke across a 3x5 grid colors %d squares, want 15", len(wide))
+	}
+	if round := brushCords(GridCord{Row: 1, Column: 1}, brushRound, 1, 3, 3); len(round) != 5 {
+		t.Errorf("round brush of radius 1 covers %v, want 5 squares", round)
+	}
+	if corner := brushCords(GridCord{}, brushSquare, 2, 3, 3); len(corner) != 9 {
+		t.Errorf("square brush in the corner covers %v, want the 9 squares on the grid", corner)
+	}
+}
+
+func TestColorSquares(t *testing.T) {
+	gridContents := makeGridLine(3)
+	h := newHistory(100)
+	h.begin()
+	colorSquares(h, gridContents, []GridCord{{Column: 0}, {Column: 2}, {Column: 9}}, rl.Red, time.Now())
+	h.end(gridContents)
+	if colorAt(gridContents, 0) != rl.Red || colorAt(gridContents, 1) != rl.Black || colorAt(gridContents, 2) != rl.Red {
+		t.Errorf("colored %v", gridContents)
+	}
+	if _, ok := gridContents[GridCord{Column: 9}]; ok {
+		t.Error("colored a square beyond the grid")
+	}
+	h.undo(gridContents)
+	if colorAt(gridContents, 0) != rl.Black || colorAt(gridContents, 2) != rl.Black {
+		t.Errorf("undo left %v", gridContents)
+	}
+}
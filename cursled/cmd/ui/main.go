@@ -1,8 +1,6 @@
 package main
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"flag"
 	"fmt"
@@ -23,8 +21,8 @@ type SquareInfo struct {
 }
 
 type GridCord struct {
-	Row    uint8
-	Column uint8
+	Row    uint16
+	Column uint16
 }
 
 var maxBrightness float32
@@ -37,7 +35,7 @@ var windowWidth int32
 var decayTime time.Duration // = 3 * time.Second
 
 func main() {
-	maxBrightness = float32(flag.Int("brightness", 50, "maximum brightness"))
+	maxBrightness = float32(*flag.Int("brightness", 50, "maximum brightness"))
 	gridColumns = int32(*flag.Int("columns", 20, "columns in grid"))
 	gridRows = int32(*flag.Int("rows", 40, "rows in grid"))
 	gridSpacing = int32(*flag.Int("spacing", 20, "grid spacing"))
@@ -65,6 +63,7 @@ func main() {
 		log.Fatal(err)
 	}
 	defer file.Close()
+	encoder := frame.NewEncoder(file)
 
 	rl.InitWindow(windowWidth, windowHeight, "pixel drawing")
 
@@ -79,7 +78,7 @@ func main() {
 		drawSquares(drawnContents, fadeMode)
 
 		if logMode {
-			exportSquares(file, drawnContents, fadeMode)
+			exportSquares(encoder, drawnContents, fadeMode)
 		}
 
 		if rl.IsKeyPressed(rl.KeyF) {
@@ -140,9 +139,8 @@ func drawSquares(squareContets map[GridCord]SquareInfo, fadeMode bool) {
 	}
 }
 
-func exportSquares(file *os.File, squares map[GridCord]SquareInfo, fadeMode bool) {
-	ledInfo := frame.LEDInfo{}
-	var binBuf bytes.Buffer
+func exportSquares(encoder *frame.Encoder, squares map[GridCord]SquareInfo, fadeMode bool) {
+	ledFrame := frame.Frame{}
 	for _, square := range squares {
 		if timeLeft := time.Now().Sub(square.CreatedAt); timeLeft < decayTime {
 			// scale for brightness
@@ -150,22 +148,16 @@ func exportSquares(file *os.File, squares map[GridCord]SquareInfo, fadeMode bool
 			if fadeMode {
 				alpha = 1.0 - float32(timeLeft.Nanoseconds())/float32(decayTime.Nanoseconds())
 			}
-			// write to I/O
-			ledInfo.Column = square.GridCord.Column
-			ledInfo.Row = square.GridCord.Row
-			ledInfo.Brightness = uint8(maxBrightness * alpha)
-			alpha = alpha
-			err := binary.Write(&binBuf, binary.BigEndian, ledInfo)
-			if err != nil {
-				panic(err)
-			}
-			_, err = file.Write(binBuf.Bytes())
-			if err != nil {
-				log.Println(binBuf)
-				panic(err)
-			}
+			ledFrame.LEDs = append(ledFrame.LEDs, frame.LEDInfo{
+				Column:     square.GridCord.Column,
+				Row:        square.GridCord.Row,
+				Brightness: uint8(maxBrightness * alpha),
+			})
 		}
 	}
+	if err := encoder.Encode(ledFrame); err != nil {
+		panic(err)
+	}
 }
 
 func squareFromCoord(vec rl.Vector2) (SquareInfo, error) { // return top left of square
@@ -180,7 +172,7 @@ func squareFromCoord(vec rl.Vector2) (SquareInfo, error) { // return top left of
 	yPos := y / gridSpacing
 
 	info := SquareInfo{
-		GridCord:  GridCord{uint8(xPos), uint8(yPos)},
+		GridCord:  GridCord{uint16(xPos), uint16(yPos)},
 		Vector2:   rl.NewVector2(float32(xPos*gridSpacing), float32(yPos*gridSpacing)),
 		CreatedAt: time.Now(),
 	}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"github.com/aaronbush/go-stuff/cursled/layout"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// wiringConfig the wiring section of the config file, how the LEDs driven by
+// the outputs are chained, e.g. four 8x32 zig-zag panels stacked on a 32x32
+// display:
+//
+//	wiring:
+//	  rotation: 0
+//	  flipHorizontal: false
+//	  flipVertical: false
+//	  panelRows: 8
+//	  panelColumns: 32
+//	  chainColumnMajor: false
+//	  chainSerpentine: false
+//	  panelRotations: [0, 180, 0, 180]
+//	  columnMajor: false
+//	  serpentine: true
+//
+// Displays that aren't grids, like rings and sculptures, give a CSV or JSON
+// file of where each LED sits instead, and the grid is sampled at those spots:
+//
+//	wiring:
+//	  points: ring.csv
+type wiringConfig struct {
+	Points           string `mapstructure:"points"`
+	Rotation         int    `mapstructure:"rotation"`
+	FlipHorizontal   bool   `mapstructure:"flipHorizontal"`
+	FlipVertical     bool   `mapstructure:"flipVertical"`
+	PanelRows        int    `mapstructure:"panelRows"`
+	PanelColumns     int    `mapstructure:"panelColumns"`
+	ChainColumnMajor bool   `mapstructure:"chainColumnMajor"`
+	ChainSerpentine  bool   `mapstructure:"chainSerpentine"`
+	PanelRotations   []int  `mapstructure:"panelRotations"`
+	ColumnMajor      bool   `mapstructure:"columnMajor"`
+	Serpentine       bool   `mapstructure:"serpentine"`
+}
+
+// addLayoutFlag adds the flag giving a coordinate file in place of the wiring settings
+func addLayoutFlag(cmd *cobra.Command) {
+	cmd.Flags().String("layout", "", "CSV or JSON file of index, x and y giving where each LED sits")
+}
+
+// loadLayout works out the wiring of a rows x columns display from the flag
+// added with addLayoutFlag or the config file; row by row when neither says
+func loadLayout(cmd *cobra.Command, rows, columns int) (layout.Layout, error) {
+	viper.BindPFlag("wiring.points", cmd.Flags().Lookup("layout"))
+	config := wiringConfig{}
+	if err := viper.UnmarshalKey("wiring", &config); err != nil {
+		return nil, err
+	}
+	if config.Points != "" {
+		points, err := layout.LoadPoints(config.Points)
+		if err != nil {
+			return nil, err
+		}
+		return points.Sample(rows, columns)
+	}
+	return layout.Wiring{
+		Rows:             rows,
+		Columns:          columns,
+		Rotation:         config.Rotation,
+		FlipHorizontal:   config.FlipHorizontal,
+		FlipVertical:     config.FlipVertical,
+		PanelRows:        config.PanelRows,
+		PanelColumns:     config.PanelColumns,
+		ChainColumnMajor: config.ChainColumnMajor,
+		ChainSerpentine:  config.ChainSerpentine,
+		PanelRotations:   config.PanelRotations,
+		ColumnMajor:      config.ColumnMajor,
+		Serpentine:       config.Serpentine,
+	}.Layout()
+}
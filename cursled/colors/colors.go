@@ -0,0 +1,124 @@
+// Package colors converts colors between the forms paint shows and takes them
+// in, and keeps palettes of them
+package colors
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseHex reads a color written #rgb, #rrggbb or #rrggbbaa; the # may be left
+// out, and colors without alpha are opaque
+func ParseHex(s string) (color.NRGBA, error) {
+	digits := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+	if len(digits) != 8 {
+		return color.NRGBA{}, fmt.Errorf("colors: %q is not #rgb, #rrggbb or #rrggbbaa", s)
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("colors: %q is not #rgb, #rrggbb or #rrggbbaa", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// Hex writes c as #rrggbb, or #rrggbbaa when it isn't opaque
+func Hex(c color.NRGBA) string {
+	if c.A == 0xFF {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// HSV a color by hue, from 0 up to 360 degrees, and saturation and value from 0 to 1
+type HSV struct {
+	H, S, V float64
+}
+
+// FromRGB the hue, saturation and value of c, ignoring its alpha
+func FromRGB(c color.NRGBA) HSV {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	high, low := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	hsv := HSV{V: high}
+	chroma := high - low
+	if chroma == 0 {
+		return hsv // grey, with no hue
+	}
+	hsv.S = chroma / high
+	switch high {
+	case r:
+		hsv.H = math.Mod((g-b)/chroma, 6)
+	case g:
+		hsv.H = (b-r)/chroma + 2
+	default:
+		hsv.H = (r-g)/chroma + 4
+	}
+	if hsv.H *= 60; hsv.H < 0 {
+		hsv.H += 360
+	}
+	return hsv
+}
+
+// RGB the opaque color of h, with its hue wrapped and saturation and value clamped
+func (h HSV) RGB() color.NRGBA {
+	hue := math.Mod(h.H, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	s, v := clamp(h.S), clamp(h.V)
+	chroma := v * s
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g = chroma, x
+	case hue < 120:
+		r, g = x, chroma
+	case hue < 180:
+		g, b = chroma, x
+	case hue < 240:
+		g, b = x, chroma
+	case hue < 300:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := v - chroma
+	return color.NRGBA{R: to8(r + m), G: to8(g + m), B: to8(b + m), A: 0xFF}
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func to8(v float64) uint8 {
+	return uint8(math.Round(clamp(v) * 255))
+}
+
+// Recent the colors last drawn with, the latest first
+type Recent struct {
+	Colors []color.NRGBA
+	Limit  int // most colors kept
+}
+
+// Add puts c first, moving it there if it was already used
+func (r *Recent) Add(c color.NRGBA) {
+	for i, used := range r.Colors {
+		if used == c {
+			r.Colors = append(r.Colors[:i], r.Colors[i+1:]...)
+			break
+		}
+	}
+	r.Colors = append([]color.NRGBA{c}, r.Colors...)
+	if len(r.Colors) > r.Limit {
+		r.Colors = r.Colors[:r.Limit]
+	}
+}
@@ -0,0 +1,87 @@
+package colors
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.NRGBA
+	}{
+		{"#ff8000", color.NRGBA{R: 0xFF, G: 0x80, A: 0xFF}},
+		{"FF8000", color.NRGBA{R: 0xFF, G: 0x80, A: 0xFF}},
+		{"#f80", color.NRGBA{R: 0xFF, G: 0x88, A: 0xFF}},
+		{" #01020304 ", color.NRGBA{R: 1, G: 2, B: 3, A: 4}},
+	}
+	for _, tt := range tests {
+		if got, err := ParseHex(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseHex(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "#ff80", "#gg0000", "#ff00001", "+ff0000"} {
+		if got, err := ParseHex(bad); err == nil {
+			t.Errorf("ParseHex(%q) = %v, want an error", bad, got)
+		}
+	}
+	if got := Hex(color.NRGBA{R: 0xFF, G: 0x80, A: 0xFF}); got != "#ff8000" {
+		t.Errorf("Hex of opaque orange = %s", got)
+	}
+	if got := Hex(color.NRGBA{R: 1, G: 2, B: 3, A: 4}); got != "#01020304" {
+		t.Errorf("Hex of a translucent color = %s", got)
+	}
+}
+
+func TestHSV(t *testing.T) {
+	tests := []struct {
+		rgb color.NRGBA
+		hsv HSV
+	}{
+		{color.NRGBA{R: 255, A: 255}, HSV{0, 1, 1}},
+		{color.NRGBA{G: 255, A: 255}, HSV{120, 1, 1}},
+		{color.NRGBA{B: 255, A: 255}, HSV{240, 1, 1}},
+		{color.NRGBA{R: 255, B: 255, A: 255}, HSV{300, 1, 1}},
+		{color.NRGBA{R: 128, G: 64, A: 255}, HSV{30, 1, 128.0 / 255}},
+		{color.NRGBA{R: 255, G: 255, B: 255, A: 255}, HSV{0, 0, 1}},
+		{color.NRGBA{A: 255}, HSV{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if got := FromRGB(tt.rgb); got != tt.hsv {
+			t.Errorf("FromRGB(%v) = %v, want %v", tt.rgb, got, tt.hsv)
+		}
+		if got := tt.hsv.RGB(); got != tt.rgb {
+			t.Errorf("%v.RGB() = %v, want %v", tt.hsv, got, tt.rgb)
+		}
+	}
+	// every 8 bit color comes back from HSV as it went in
+	for r := 0; r < 256; r += 5 {
+		for g := 0; g < 256; g += 3 {
+			for b := 0; b < 256; b += 7 {
+				c := color.NRGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
+				if got := FromRGB(c).RGB(); got != c {
+					t.Fatalf("%v came back as %v", c, got)
+				}
+			}
+		}
+	}
+	if got := (HSV{H: -240, S: 2, V: 1}).RGB(); got != (color.NRGBA{G: 255, A: 255}) {
+		t.Errorf("out of range HSV = %v, want green", got)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	red, green, blue := color.NRGBA{R: 255}, color.NRGBA{G: 255}, color.NRGBA{B: 255}
+	r := Recent{Limit: 2}
+	r.Add(red)
+	r.Add(green)
+	r.Add(red)
+	if want := []color.NRGBA{red, green}; !reflect.DeepEqual(r.Colors, want) {
+		t.Errorf("reusing red: %v, want %v", r.Colors, want)
+	}
+	r.Add(blue)
+	if want := []color.NRGBA{blue, red}; !reflect.DeepEqual(r.Colors, want) {
+		t.Errorf("past the limit: %v, want %v", r.Colors, want)
+	}
+}
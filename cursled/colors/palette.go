@@ -0,0 +1,159 @@
+package colors
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Palette a named set of colors to pick from
+type Palette struct {
+	Name   string
+	Colors []Swatch
+}
+
+// Swatch one color of a palette; the name may be empty
+type Swatch struct {
+	Name  string
+	Color color.NRGBA
+}
+
+// gplMagic the first line of a GIMP palette
+const gplMagic = "GIMP Palette"
+
+// paletteDocument the JSON form of a palette, with colors written as by Hex
+type paletteDocument struct {
+	Name   string         `json:"name"`
+	Colors []paletteColor `json:"colors"`
+}
+
+type paletteColor struct {
+	Name  string `json:"name,omitempty"`
+	Color string `json:"color"`
+}
+
+// Load reads a GIMP palette (.gpl) or a JSON palette (.json), by the extension of path
+func Load(path string) (Palette, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Palette{}, err
+	}
+	defer file.Close()
+	var p Palette
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gpl":
+		p, err = ReadGPL(file)
+	case ".json":
+		p, err = ReadJSON(file)
+	default:
+		return Palette{}, fmt.Errorf("colors: can't read a palette from %q; use .gpl or .json", path)
+	}
+	if err != nil {
+		return Palette{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Save writes p to path as a GIMP palette or JSON, by its extension
+func Save(path string, p Palette) error {
+	var write func(io.Writer, Palette) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gpl":
+		write = WriteGPL
+	case ".json":
+		write = WriteJSON
+	default:
+		return fmt.Errorf("colors: can't save a palette as %q; use .gpl or .json", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, p); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadGPL reads a GIMP palette: a header, then a line of red, green and blue
+// from 0 to 255 and an optional name for each color
+func ReadGPL(r io.Reader) (Palette, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != gplMagic {
+		return Palette{}, errors.New("colors: not a GIMP palette")
+	}
+	p := Palette{}
+	for line := 2; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "Columns:"):
+			continue
+		case strings.HasPrefix(text, "Name:"):
+			p.Name = strings.TrimSpace(strings.TrimPrefix(text, "Name:"))
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return Palette{}, fmt.Errorf("colors: line %d: %q is not red green blue [name]", line, text)
+		}
+		var rgb [3]uint8
+		for i := range rgb {
+			v, err := strconv.ParseUint(fields[i], 10, 8)
+			if err != nil {
+				return Palette{}, fmt.Errorf("colors: line %d: %q is not a channel from 0 to 255", line, fields[i])
+			}
+			rgb[i] = uint8(v)
+		}
+		p.Colors = append(p.Colors, Swatch{
+			Name:  strings.Join(fields[3:], " "),
+			Color: color.NRGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 0xFF},
+		})
+	}
+	return p, scanner.Err()
+}
+
+// WriteGPL writes p as a GIMP palette; GIMP palettes have no alpha, so it is dropped
+func WriteGPL(w io.Writer, p Palette) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s\nName: %s\nColumns: 8\n#\n", gplMagic, p.Name)
+	for _, s := range p.Colors {
+		fmt.Fprintf(bw, "%3d %3d %3d\t%s\n", s.Color.R, s.Color.G, s.Color.B, s.Name)
+	}
+	return bw.Flush()
+}
+
+// ReadJSON reads a palette written by WriteJSON
+func ReadJSON(r io.Reader) (Palette, error) {
+	doc := paletteDocument{}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return Palette{}, err
+	}
+	p := Palette{Name: doc.Name}
+	for _, c := range doc.Colors {
+		parsed, err := ParseHex(c.Color)
+		if err != nil {
+			return Palette{}, err
+		}
+		p.Colors = append(p.Colors, Swatch{Name: c.Name, Color: parsed})
+	}
+	return p, nil
+}
+
+// WriteJSON writes p as {"name": ..., "colors": [{"name": ..., "color": "#rrggbb"}, ...]}
+func WriteJSON(w io.Writer, p Palette) error {
+	doc := paletteDocument{Name: p.Name, Colors: []paletteColor{}}
+	for _, s := range p.Colors {
+		doc.Colors = append(doc.Colors, paletteColor{Name: s.Name, Color: Hex(s.Color)})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
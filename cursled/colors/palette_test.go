@@ -0,0 +1,64 @@
+package colors
+
+import (
+	"bytes"
+	"image/color"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testPalette = Palette{Name: "Fire", Colors: []Swatch{
+	{Name: "deep red", Color: color.NRGBA{R: 139, A: 255}},
+	{Color: color.NRGBA{R: 255, G: 165, A: 255}},
+}}
+
+func TestGPL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGPL(&buf, testPalette); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ReadGPL(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, testPalette) {
+		t.Errorf("read back %v, want %v", p, testPalette)
+	}
+
+	gimp := "GIMP Palette\r\nName: Web\nColumns: 4\n# a comment\n\n  0   0 255\tBlue  Sky\n"
+	if p, err := ReadGPL(strings.NewReader(gimp)); err != nil || p.Name != "Web" || len(p.Colors) != 1 || p.Colors[0].Name != "Blue Sky" {
+		t.Errorf("GIMP's own palette read as %v, %v", p, err)
+	}
+	for _, bad := range []string{"", "JASC-PAL\n", "GIMP Palette\n0 0\n", "GIMP Palette\n0 256 0\n"} {
+		if _, err := ReadGPL(strings.NewReader(bad)); err == nil {
+			t.Errorf("read %q", bad)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	translucent := testPalette
+	translucent.Colors = append([]Swatch{{Name: "glass", Color: color.NRGBA{R: 10, G: 20, B: 30, A: 40}}}, testPalette.Colors...)
+	path := filepath.Join(dir, "fire.json")
+	if err := Save(path, translucent); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := Load(path); err != nil || !reflect.DeepEqual(p, translucent) {
+		t.Errorf("JSON read back %v, %v, want %v", p, err, translucent)
+	}
+
+	path = filepath.Join(dir, "fire.gpl")
+	if err := Save(path, testPalette); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := Load(path); err != nil || !reflect.DeepEqual(p, testPalette) {
+		t.Errorf("GPL read back %v, %v, want %v", p, err, testPalette)
+	}
+
+	if err := Save(filepath.Join(dir, "fire.aco"), testPalette); err == nil {
+		t.Error("saved a palette as .aco")
+	}
+}
@@ -0,0 +1,63 @@
+package frame
+
+import (
+	"fmt"
+	"math"
+)
+
+// Calibration corrects colors for how real LEDs show them: a gamma curve so that
+// picked colors don't look washed out, per-channel scales for white balance and
+// a cap on brightness.  Build one with NewCalibration.
+type Calibration struct {
+	Gamma         float64 // exponent applied to each channel; 1 leaves colors as they are
+	Red           float64 // scale for the red channel after gamma, 0-1
+	Green         float64 // scale for the green channel after gamma, 0-1
+	Blue          float64 // scale for the blue channel after gamma, 0-1
+	MaxBrightness uint8   // brightness is never sent above this
+
+	tables [3][256]uint8 // red, green and blue lookups built from the fields above
+}
+
+// DefaultGamma the gamma curve commonly used for WS2812 LEDs
+const DefaultGamma = 2.8
+
+// NewCalibration returns a Calibration with its lookup tables built
+func NewCalibration(gamma, red, green, blue float64, maxBrightness uint8) (*Calibration, error) {
+	if gamma <= 0 || math.IsNaN(gamma) || math.IsInf(gamma, 0) {
+		return nil, fmt.Errorf("frame: gamma %v must be a positive number", gamma)
+	}
+	for _, scale := range []float64{red, green, blue} {
+		if scale < 0 || scale > 1 || math.IsNaN(scale) {
+			return nil, fmt.Errorf("frame: channel scale %v must be between 0 and 1", scale)
+		}
+	}
+
+	c := &Calibration{Gamma: gamma, Red: red, Green: green, Blue: blue, MaxBrightness: maxBrightness}
+	for channel, scale := range []float64{red, green, blue} {
+		for v := range c.tables[channel] {
+			c.tables[channel][v] = uint8(math.Round(math.Pow(float64(v)/255, gamma) * scale * 255))
+		}
+	}
+	return c, nil
+}
+
+// Apply returns led with its colors corrected and its brightness capped
+func (c *Calibration) Apply(led LEDInfo) LEDInfo {
+	led.Red = c.tables[0][led.Red]
+	led.Green = c.tables[1][led.Green]
+	led.Blue = c.tables[2][led.Blue]
+	if led.Brightness > c.MaxBrightness {
+		led.Brightness = c.MaxBrightness
+	}
+	return led
+}
+
+// ApplyFrame returns a copy of f with every LED calibrated, leaving f untouched
+func (c *Calibration) ApplyFrame(f Frame) Frame {
+	leds := make([]LEDInfo, len(f.LEDs))
+	for i, led := range f.LEDs {
+		leds[i] = c.Apply(led)
+	}
+	f.LEDs = leds
+	return f
+}
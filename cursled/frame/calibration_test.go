@@ -0,0 +1,84 @@
+package frame
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewCalibrationRejects(t *testing.T) {
+	tests := []struct {
+		name             string
+		gamma            float64
+		red, green, blue float64
+	}{
+		{"zero gamma", 0, 1, 1, 1},
+		{"negative gamma", -1, 1, 1, 1},
+		{"scale above one", 2.8, 1.5, 1, 1},
+		{"negative scale", 2.8, 1, -0.1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCalibration(tt.gamma, tt.red, tt.green, tt.blue, 255); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCalibrationApply(t *testing.T) {
+	c, err := NewCalibration(2, 1, 0.5, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	led := LEDInfo{Row: 1, Column: 2, Red: 0x80, Green: 0xFF, Blue: 0xFF, Brightness: 200}
+	want := LEDInfo{Row: 1, Column: 2, Red: 0x40, Green: 0x80, Blue: 0, Brightness: 100}
+	if got := c.Apply(led); got != want {
+		t.Errorf("Apply(%+v) = %+v, want %+v", led, got, want)
+	}
+
+	dim := LEDInfo{Red: 0xFF, Brightness: 50}
+	if got := c.Apply(dim); got.Brightness != 50 || got.Red != 0xFF {
+		t.Errorf("Apply(%+v) = %+v, want brightness left at 50", dim, got)
+	}
+}
+
+func TestIdentityCalibration(t *testing.T) {
+	c, err := NewCalibration(1, 1, 1, 1, 255)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v := 0; v < 256; v++ {
+		led := LEDInfo{Red: uint8(v), Green: uint8(v), Blue: uint8(v), Brightness: uint8(v)}
+		if got := c.Apply(led); got != led {
+			t.Fatalf("Apply(%+v) = %+v", led, got)
+		}
+	}
+}
+
+func TestEncoderCalibration(t *testing.T) {
+	c, err := NewCalibration(2, 1, 1, 1, 255)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := makeFrame(3)
+	original := append([]LEDInfo(nil), f.LEDs...)
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Calibration = c
+	if err := enc.Encode(f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, led := range got.LEDs {
+		if want := c.Apply(original[i]); led != want {
+			t.Errorf("LED %d = %+v, want %+v", i, led, want)
+		}
+		if f.LEDs[i] != original[i] {
+			t.Errorf("Encode changed LED %d of the caller's frame", i)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// A recording is a file header, the frames as an Encoder wrote them and a
+// trailing index of where each frame starts:
+//
+//	"LEDR" ContainerVersion ContainerHeader
+//	frame...
+//	"LIDX" count uint32 IndexEntry...
+//	index offset uint64 "LEND"
+const (
+	containerMagic   = "LEDR"
+	indexMagic       = "LIDX"
+	trailerMagic     = "LEND"
+	ContainerVersion = 1
+)
+
+// trailerSize the index offset and closing magic at the very end of a recording
+const trailerSize = 8 + 4
+
+// ErrNotRecording is returned when a file does not start with a recording header
+var ErrNotRecording = errors.New("frame: not a recording")
+
+// ErrNoIndex is returned when a recording has no index, e.g. because it was never closed
+var ErrNoIndex = errors.New("frame: recording has no index")
+
+// ErrRecordingVersion1 is returned when recording Version1 frames, which carry no timestamps
+var ErrRecordingVersion1 = errors.New("frame: recordings need versioned frames")
+
+// ContainerHeader describes the display a recording was made for
+type ContainerHeader struct {
+	Rows        uint16
+	Columns     uint16
+	PixelFormat uint8
+	FPS         uint16 // nominal frames per second; each frame carries its own timing
+}
+
+// IndexEntry locates one frame of a recording
+type IndexEntry struct {
+	Offset    uint64 // from the start of the file to the frame's sentinel
+	Timestamp uint32 // milliseconds, as in the frame's header
+	Flags     uint8  // as in the frame's header
+}
+
+// IsKeyFrame reports whether the frame can be shown without the frames before it
+func (e IndexEntry) IsKeyFrame() bool {
+	return e.Flags&FlagDelta == 0
+}
+
+// Writer records the frames an Encoder writes to it, indexing each one.  Close
+// must be called to write the index.
+type Writer struct {
+	w      io.Writer
+	offset uint64
+	index  []IndexEntry
+}
+
+// NewWriter writes the recording header to w and returns a Writer to hand to an Encoder
+func NewWriter(w io.Writer, header ContainerHeader) (*Writer, error) {
+	buf := bytes.NewBufferString(containerMagic)
+	buf.WriteByte(ContainerVersion)
+	binary.Write(buf, binary.BigEndian, header)
+	n, err := w.Write(buf.Bytes())
+	return &Writer{w: w, offset: uint64(n)}, err
+}
+
+// Write records one whole frame; an Encoder writes each frame with a single call
+func (w *Writer) Write(p []byte) (int, error) {
+	wire := headerV2{}
+	if len(p) < 5+binary.Size(wire) || binary.BigEndian.Uint32(p) != VersionedSentinel {
+		return 0, ErrRecordingVersion1
+	}
+	binary.Read(bytes.NewReader(p[5:]), binary.BigEndian, &wire)
+
+	n, err := w.w.Write(p)
+	if err == nil {
+		w.index = append(w.index, IndexEntry{Offset: w.offset, Timestamp: wire.Timestamp, Flags: wire.Flags})
+	}
+	w.offset += uint64(n)
+	return n, err
+}
+
+// Close writes the index and trailer; it does not close the underlying writer
+func (w *Writer) Close() error {
+	buf := bytes.NewBufferString(indexMagic)
+	binary.Write(buf, binary.BigEndian, uint32(len(w.index)))
+	binary.Write(buf, binary.BigEndian, w.index)
+	binary.Write(buf, binary.BigEndian, w.offset)
+	buf.WriteString(trailerMagic)
+	_, err := w.w.Write(buf.Bytes())
+	return err
+}
+
+// Reader plays back a recording, jumping to any frame through its index
+type Reader struct {
+	rs      io.ReadSeeker
+	header  ContainerHeader
+	index   []IndexEntry
+	end     int64 // where the frames stop and the index starts
+	next    int   // the frame Decode returns next
+	decoder *Decoder
+}
+
+// NewReader reads the header and index of the recording in rs
+func NewReader(rs io.ReadSeeker) (*Reader, error) {
+	r := &Reader{rs: rs}
+	start := make([]byte, len(containerMagic)+1)
+	if _, err := io.ReadFull(rs, start); err != nil || string(start[:4]) != containerMagic {
+		return nil, ErrNotRecording
+	}
+	if start[4] != ContainerVersion {
+		return nil, fmt.Errorf("frame: unsupported recording version %d", start[4])
+	}
+	if err := binary.Read(rs, binary.BigEndian, &r.header); err != nil {
+		return nil, ErrNotRecording
+	}
+
+	size, err := rs.Seek(-trailerSize, io.SeekEnd)
+	if err != nil {
+		return nil, ErrNoIndex
+	}
+	trailer := make([]byte, trailerSize)
+	if _, err := io.ReadFull(rs, trailer); err != nil || string(trailer[8:]) != trailerMagic {
+		return nil, ErrNoIndex
+	}
+	r.end = int64(binary.BigEndian.Uint64(trailer))
+	if r.end > size {
+		return nil, ErrNoIndex
+	}
+
+	if _, err = rs.Seek(r.end, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var count uint32
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(rs, magic); err != nil || string(magic) != indexMagic {
+		return nil, ErrNoIndex
+	}
+	if err := binary.Read(rs, binary.BigEndian, &count); err != nil {
+		return nil, ErrNoIndex
+	}
+	if int64(count)*int64(binary.Size(IndexEntry{})) > size-r.end {
+		return nil, ErrNoIndex
+	}
+	r.index = make([]IndexEntry, count)
+	if err := binary.Read(rs, binary.BigEndian, r.index); err != nil {
+		return nil, ErrNoIndex
+	}
+	return r, r.Seek(0)
+}
+
+// Header returns the recording's file header
+func (r *Reader) Header() ContainerHeader {
+	return r.header
+}
+
+// Len the number of frames in the recording
+func (r *Reader) Len() int {
+	return len(r.index)
+}
+
+// Entry returns the index entry of frame n
+func (r *Reader) Entry(n int) IndexEntry {
+	return r.index[n]
+}
+
+// Search returns the frame showing at time t: the last one starting at or before it
+func (r *Reader) Search(t time.Duration) int {
+	ms := uint32(t / time.Millisecond)
+	n := sort.Search(len(r.index), func(i int) bool { return r.index[i].Timestamp > ms })
+	if n > 0 {
+		n--
+	}
+	return n
+}
+
+// Seek arranges for the next Decode to return frame n
+func (r *Reader) Seek(n int) error {
+	if n < 0 || n > len(r.index) {
+		return fmt.Errorf("frame: no frame %d in a recording of %d", n, len(r.index))
+	}
+	offset := r.end
+	if n < len(r.index) {
+		offset = int64(r.index[n].Offset)
+	}
+	if _, err := r.rs.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.decoder = NewDecoder(io.LimitReader(r.rs, r.end-offset))
+	r.next = n
+	return nil
+}
+
+// Decode returns the next frame, or io.EOF after the last one.  A damaged frame
+// is a CorruptFrameError, and the next Decode returns the frame after it.
+func (r *Reader) Decode() (Frame, error) {
+	if r.next >= len(r.index) {
+		return Frame{}, io.EOF
+	}
+	corrupt := r.decoder.Stats().Corrupt
+	f, err := r.decoder.Decode()
+	if r.decoder.Stats().Corrupt != corrupt {
+		// the decoder rescanned past the frame and may have returned a later one,
+		// so start again where the index says the next frame is
+		n := r.next
+		if err := r.Seek(n + 1); err != nil {
+			return Frame{}, err
+		}
+		return Frame{}, &CorruptFrameError{Frame: n}
+	}
+	if err == nil {
+		r.next++
+	}
+	return f, err
+}
+
+// State rebuilds the display as it was after frame n, decoding from the key frame
+// before it.  The next Decode returns frame n+1.
+func (r *Reader) State(n int) (*State, error) {
+	if n < 0 || n >= len(r.index) {
+		return nil, fmt.Errorf("frame: no frame %d in a recording of %d", n, len(r.index))
+	}
+	key := n
+	for key > 0 && !r.index[key].IsKeyFrame() {
+		key--
+	}
+	if err := r.Seek(key); err != nil {
+		return nil, err
+	}
+	state := NewState()
+	for r.next <= n {
+		f, err := r.Decode()
+		if err != nil {
+			return nil, err
+		}
+		state.Apply(f)
+	}
+	return state, nil
+}
@@ -0,0 +1,160 @@
+package frame
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// record writes a recording of n frames, 100ms apart, each lighting one more LED
+func record(t *testing.T, n, keyInterval int) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, ContainerHeader{Rows: 4, Columns: 8, PixelFormat: PixelRGBBrightness, FPS: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := NewDeltaEncoder(NewEncoder(w), keyInterval)
+	for i := 0; i < n; i++ {
+		f := makeFrame(i + 1)
+		f.Header.Timestamp = uint32(i * 100)
+		if err := enc.Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(record(t, 12, 5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ContainerHeader{Rows: 4, Columns: 8, PixelFormat: PixelRGBBrightness, FPS: 10}); r.Header() != want {
+		t.Errorf("Header() = %+v, want %+v", r.Header(), want)
+	}
+	if r.Len() != 12 {
+		t.Fatalf("Len() = %d, want 12", r.Len())
+	}
+	for i := 0; i < r.Len(); i++ {
+		if key := i%5 == 0; r.Entry(i).IsKeyFrame() != key {
+			t.Errorf("Entry(%d).IsKeyFrame() = %t, want %t", i, !key, key)
+		}
+	}
+
+	for i := 0; ; i++ {
+		f, err := r.Decode()
+		if err == io.EOF {
+			if i != 12 {
+				t.Errorf("decoded %d frames, want 12", i)
+			}
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if f.Header.Timestamp != uint32(i*100) {
+			t.Errorf("frame %d timestamp = %d, want %d", i, f.Header.Timestamp, i*100)
+		}
+	}
+}
+
+func TestRecordingSeek(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(record(t, 12, 5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Seek(7); err != nil {
+		t.Fatal(err)
+	}
+	f, err := r.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Header.Timestamp != 700 || f.Header.IsKeyFrame() {
+		t.Errorf("frame 7 = %+v, want the delta frame at 700ms", f.Header)
+	}
+
+	state, err := r.State(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(state.LEDs()); !state.Synced() || got != 9 {
+		t.Errorf("State(8) has %d LEDs, synced %t; want 9 synced", got, state.Synced())
+	}
+	if next, err := r.Decode(); err != nil || next.Header.Timestamp != 900 {
+		t.Errorf("Decode after State(8) = %+v, %v; want frame 9", next.Header, err)
+	}
+
+	searches := []struct {
+		t    time.Duration
+		want int
+	}{
+		{0, 0},
+		{99 * time.Millisecond, 0},
+		{100 * time.Millisecond, 1},
+		{750 * time.Millisecond, 7},
+		{time.Hour, 11},
+	}
+	for _, s := range searches {
+		if got := r.Search(s.t); got != s.want {
+			t.Errorf("Search(%v) = %d, want %d", s.t, got, s.want)
+		}
+	}
+}
+
+func TestRecordingCorruptFrame(t *testing.T) {
+	data := record(t, 6, 10)
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[r.Entry(3).Offset-1] ^= 0xFF // the last byte of frame 2's checksum
+	if r, err = NewReader(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < r.Len(); i++ {
+		f, err := r.Decode()
+		if i == 2 {
+			if e, ok := err.(*CorruptFrameError); !ok || e.Frame != 2 {
+				t.Errorf("Decode(frame 2) = %v, want a CorruptFrameError for frame 2", err)
+			}
+			continue
+		}
+		if err != nil || f.Header.Timestamp != uint32(i*100) {
+			t.Errorf("Decode(frame %d) = %+v, %v; want the frame at %dms", i, f.Header, err, i*100)
+		}
+	}
+	if _, err := r.Decode(); err != io.EOF {
+		t.Errorf("Decode after the last frame = %v, want io.EOF", err)
+	}
+	if _, err := r.State(4); err == nil {
+		t.Error("State(4) rebuilt the display through a corrupt frame")
+	}
+}
+
+func TestRecordingErrors(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader(encodeFrames(t, makeFrame(3)))); err != ErrNotRecording {
+		t.Errorf("NewReader(stream) = %v, want ErrNotRecording", err)
+	}
+
+	unclosed := record(t, 3, 5)
+	unclosed = unclosed[:len(unclosed)-trailerSize-1]
+	if _, err := NewReader(bytes.NewReader(unclosed)); err != ErrNoIndex {
+		t.Errorf("NewReader(unclosed) = %v, want ErrNoIndex", err)
+	}
+
+	w, err := NewWriter(&bytes.Buffer{}, ContainerHeader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := NewEncoder(w)
+	enc.Version = Version1
+	if err := enc.Encode(makeFrame(1)); err != ErrRecordingVersion1 {
+		t.Errorf("Encode(Version1) = %v, want ErrRecordingVersion1", err)
+	}
+}
@@ -0,0 +1,339 @@
+package frame
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Stats counts what a Decoder has seen so far
+type Stats struct {
+	Frames  uint64 // frames successfully decoded
+	Dropped uint64 // frames missing according to gaps in the sequence numbers
+	Corrupt uint64 // frames discarded because their checksum did not match
+	Skipped uint64 // bytes discarded while searching for a start sentinel
+}
+
+// Decoder reads whole frames from an underlying reader.  Bytes that are not
+// part of a valid frame are skipped until the next sentinel is found.
+type Decoder struct {
+	r            *bufio.Reader
+	frame        []byte // raw bytes of the frame being decoded, from its sentinel on
+	replay       []byte // bytes to scan again before reading more from r
+	stats        Stats
+	lastSequence uint32
+	haveSequence bool
+
+	// MaxLEDs frames describing more LEDs than this are skipped with an OversizeError
+	MaxLEDs int
+	// MaxVersion frames newer than this are rejected with an UnsupportedVersionError
+	MaxVersion uint8
+}
+
+// NewDecoder returns a Decoder that reads frames of any supported version from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), MaxLEDs: MaxLEDs, MaxVersion: CurrentVersion}
+}
+
+// Stats returns the counters for the frames read so far
+func (d *Decoder) Stats() Stats {
+	return d.stats
+}
+
+// Decode reads the next frame.  Frames failing their checksum, or claiming an
+// encoding or pixel format that doesn't exist, are counted and dropped, and the
+// stream is rescanned for the next sentinel.  It returns io.EOF
+// only when the stream ends outside of a frame; a stream ending inside a frame
+// is a TruncatedError.
+func (d *Decoder) Decode() (Frame, error) {
+	for {
+		f, err := d.decode()
+		switch err.(type) {
+		case corruptError:
+			d.stats.Corrupt++
+			d.rescan()
+			continue
+		case *TruncatedError:
+			// a corrupt LED count can swallow the frames after it; look for them
+			if bytes.Contains(d.frame[1:], sentinelBytes(StartSentinel)) ||
+				bytes.Contains(d.frame[1:], sentinelBytes(VersionedSentinel)) {
+				d.stats.Corrupt++
+				d.rescan()
+				continue
+			}
+		case *UnsupportedVersionError:
+			d.rescan()
+		}
+		return f, err
+	}
+}
+
+func (d *Decoder) decode() (Frame, error) {
+	f := Frame{}
+
+	sentinel, err := d.sync()
+	if err != nil {
+		return f, err
+	}
+
+	switch sentinel {
+	case StartSentinel:
+		err = d.readHeaderV1(&f.Header)
+	case VersionedSentinel:
+		err = d.readVersionedHeader(&f.Header)
+	}
+	if err != nil {
+		return f, err
+	}
+
+	// only versioned frames name their encoding and pixel format, and they are
+	// checksummed, so an unknown one is a damaged byte rather than a newer sender
+	codec, err := pixelCodec(f.Header.PixelFormat)
+	if err != nil {
+		return f, corruptError(fmt.Sprintf("unknown pixel format %d", f.Header.PixelFormat))
+	}
+
+	payloadStart := len(d.frame)
+	switch f.Header.Encoding {
+	case EncodingLEDs:
+		_, err = d.read("LEDs", int(f.Header.NumLEDs)*recordSize(f.Header, codec))
+	case EncodingDense:
+		err = d.readDense(f.Header, codec)
+	case EncodingRLE:
+		err = d.readRLE(f.Header, codec)
+	default:
+		return f, corruptError(fmt.Sprintf("unknown payload encoding %d", f.Header.Encoding))
+	}
+	if err != nil {
+		return f, err
+	}
+	payload := d.frame[payloadStart:]
+
+	if f.Header.Version != Version1 {
+		sum, err := d.read("checksum", 4)
+		if err != nil {
+			return f, err
+		}
+		if binary.BigEndian.Uint32(sum) != crc32.ChecksumIEEE(d.frame[4:len(d.frame)-4]) {
+			return f, corruptError("checksum mismatch")
+		}
+	}
+
+	if numLEDs := int(f.Header.NumLEDs); numLEDs > d.MaxLEDs {
+		return f, &OversizeError{NumLEDs: numLEDs, Max: d.MaxLEDs}
+	}
+	f.LEDs = decodePayload(f.Header, codec, payload)
+
+	d.count(f.Header)
+	return f, nil
+}
+
+func (d *Decoder) readHeaderV1(header *Header) error {
+	numLEDs, err := d.read("header", 2)
+	if err != nil {
+		return err
+	}
+	header.Version = Version1
+	header.NumLEDs = binary.BigEndian.Uint16(numLEDs)
+	return nil
+}
+
+func (d *Decoder) readVersionedHeader(header *Header) error {
+	version, err := d.read("header", 1)
+	if err != nil {
+		return err
+	}
+	header.Version = version[0]
+	if header.Version != Version2 || header.Version > d.MaxVersion {
+		return &UnsupportedVersionError{Version: header.Version}
+	}
+
+	wire := headerV2{}
+	raw, err := d.read("header", binary.Size(wire))
+	if err != nil {
+		return err
+	}
+	binary.Read(bytes.NewReader(raw), binary.BigEndian, &wire)
+	header.Flags = wire.Flags
+	header.Encoding = wire.Encoding
+	header.PixelFormat = wire.PixelFormat
+	header.NumLEDs = wire.NumLEDs
+	header.Sequence = wire.Sequence
+	header.Timestamp = wire.Timestamp
+	header.Duration = wire.Duration
+	return nil
+}
+
+// readDense reads the grid size and pixels of an EncodingDense payload
+func (d *Decoder) readDense(header Header, codec PixelCodec) error {
+	numPixels, err := d.readGridSize(header)
+	if err != nil {
+		return err
+	}
+	_, err = d.read("pixels", numPixels*codec.Size())
+	return err
+}
+
+// readRLE reads the grid size and runs of an EncodingRLE payload
+func (d *Decoder) readRLE(header Header, codec PixelCodec) error {
+	numPixels, err := d.readGridSize(header)
+	if err != nil {
+		return err
+	}
+	for covered := 0; covered < numPixels; {
+		run, err := d.read("runs", 1+codec.Size())
+		if err != nil {
+			return err
+		}
+		if run[0] == 0 || covered+int(run[0]) > numPixels {
+			return corruptError("run overflows the grid")
+		}
+		covered += int(run[0])
+	}
+	return nil
+}
+
+func (d *Decoder) readGridSize(header Header) (int, error) {
+	size, err := d.read("grid size", 4)
+	if err != nil {
+		return 0, err
+	}
+	rows, columns := int(binary.BigEndian.Uint16(size)), int(binary.BigEndian.Uint16(size[2:]))
+	if rows*columns != int(header.NumLEDs) {
+		return 0, corruptError("grid size does not match LED count")
+	}
+	return rows * columns, nil
+}
+
+// decodePayload turns a payload read according to header back into LEDs
+func decodePayload(header Header, codec PixelCodec, payload []byte) []LEDInfo {
+	leds := make([]LEDInfo, header.NumLEDs)
+	if header.Encoding == EncodingLEDs {
+		size, wide := recordSize(header, codec), header.Flags&FlagWideCoordinates != 0
+		for i := range leds {
+			leds[i] = ledRecord(payload[i*size:], wide, codec)
+		}
+		return leds
+	}
+
+	columns := int(binary.BigEndian.Uint16(payload[2:]))
+	for i := range leds {
+		leds[i].Row, leds[i].Column = uint16(i/columns), uint16(i%columns)
+	}
+	payload = payload[4:]
+	pixelSize := codec.Size()
+	if header.Encoding == EncodingDense {
+		for i := range leds {
+			codec.Get(payload[i*pixelSize:], &leds[i])
+		}
+		return leds
+	}
+	for i := 0; len(payload) > 0; payload = payload[1+pixelSize:] {
+		for run := int(payload[0]); run > 0; run-- {
+			codec.Get(payload[1:], &leds[i])
+			i++
+		}
+	}
+	return leds
+}
+
+// recordSize the size of each LED record in an EncodingLEDs payload
+func recordSize(header Header, codec PixelCodec) int {
+	return coordinateSize(header.Flags&FlagWideCoordinates != 0) + codec.Size()
+}
+
+// count updates the stats for a decoded frame; only versioned frames carry sequence numbers
+func (d *Decoder) count(header Header) {
+	d.stats.Frames++
+	if header.Version == Version1 {
+		return
+	}
+	if d.haveSequence && header.Sequence > d.lastSequence {
+		d.stats.Dropped += uint64(header.Sequence - d.lastSequence - 1)
+	}
+	d.lastSequence, d.haveSequence = header.Sequence, true
+}
+
+// sync discards bytes until either sentinel has been read and starts a new frame with it
+func (d *Decoder) sync() (uint32, error) {
+	d.frame = d.frame[:0]
+	var window uint32
+	for n := 1; ; n++ {
+		b, err := d.readByte()
+		if err == io.EOF {
+			d.stats.Skipped += uint64(n - 1)
+			return 0, io.EOF
+		} else if err != nil {
+			return 0, err
+		}
+		window = window<<8 | uint32(b)
+		if n >= 4 && (window == StartSentinel || window == VersionedSentinel) {
+			d.stats.Skipped += uint64(n - 4)
+			d.frame = append(d.frame, sentinelBytes(window)...)
+			return window, nil
+		}
+	}
+}
+
+// rescan arranges for the bytes of the current frame after its first to be scanned again
+func (d *Decoder) rescan() {
+	if len(d.frame) == 0 {
+		return
+	}
+	replay := make([]byte, 0, len(d.frame)-1+len(d.replay))
+	replay = append(replay, d.frame[1:]...)
+	d.replay = append(replay, d.replay...)
+	d.frame = d.frame[:0]
+	d.stats.Skipped++
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	if len(d.replay) > 0 {
+		b := d.replay[0]
+		d.replay = d.replay[1:]
+		return b, nil
+	}
+	return d.r.ReadByte()
+}
+
+// read appends the next n bytes to the current frame and returns them
+func (d *Decoder) read(part string, n int) ([]byte, error) {
+	start := len(d.frame)
+	if cap(d.frame) < start+n {
+		grown := make([]byte, start, 2*(start+n))
+		copy(grown, d.frame)
+		d.frame = grown
+	}
+	d.frame = d.frame[:start+n]
+	buf := d.frame[start:]
+
+	got := copy(buf, d.replay)
+	d.replay = d.replay[got:]
+	if got < n {
+		m, err := io.ReadFull(d.r, buf[got:])
+		got += m
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			d.frame = d.frame[:start+got]
+			return nil, &TruncatedError{Part: part, Want: n, Got: got}
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// corruptError marks a frame that failed its checksum or could not be laid out as described
+type corruptError string
+
+func (e corruptError) Error() string {
+	return "frame: corrupt frame, " + string(e)
+}
+
+func sentinelBytes(sentinel uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, sentinel)
+	return b
+}
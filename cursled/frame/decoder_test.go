@@ -0,0 +1,260 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func makeFrame(numLEDs int) Frame {
+	f := Frame{}
+	for n := 0; n < numLEDs; n++ {
+		f.LEDs = append(f.LEDs, LEDInfo{Row: uint16(n / 20), Column: uint16(n % 20), Red: uint8(n), Brightness: 0xFF})
+	}
+	return f
+}
+
+func encodeFrames(t *testing.T, frames ...Frame) []byte {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, f := range frames {
+		if err := enc.Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeVersion1WireFormat(t *testing.T) {
+	f := Frame{LEDs: []LEDInfo{{Row: 1, Column: 2, Red: 0xFF, Green: 0x00, Blue: 0x80, Brightness: 0x7F}}}
+	want := []byte{0xDE, 0xAD, 0xBE, 0xEF, // start sentinel
+		0x00, 0x01, // numLEDs
+		0x01, 0x02, // row, column
+		0xFF, 0x00, 0x80, // RGB
+		0x7F, // Brightness
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Version = Version1
+	if err := enc.Encode(f); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("Encode() = % x, want % x", got, want)
+	}
+}
+
+func TestEncodeVersion2WireFormat(t *testing.T) {
+	f := Frame{
+		Header: Header{Timestamp: 0x01020304, Duration: 0x0021},
+		LEDs:   []LEDInfo{{Row: 1, Column: 2, Red: 0xFF, Green: 0x00, Blue: 0x80, Brightness: 0x7F}},
+	}
+	want := []byte{0xDE, 0xAD, 0xC0, 0xDE, // versioned sentinel
+		0x02,       // version
+		0x00,       // flags
+		0x00,       // encoding
+		0x00,       // pixel format
+		0x00, 0x01, // numLEDs
+		0x00, 0x00, 0x00, 0x01, // sequence
+		0x01, 0x02, 0x03, 0x04, // timestamp
+		0x00, 0x21, // duration
+		0x01, 0x02, // row, column
+		0xFF, 0x00, 0x80, // RGB
+		0x7F, // Brightness
+	}
+	got := encodeFrames(t, Frame{}, f)
+	got, sum := got[len(got)-len(want)-4:len(got)-4], got[len(got)-4:]
+	if !bytes.Equal(got, want) {
+		t.Errorf("Encode() = % x, want % x", got, want)
+	}
+	if crc := crc32.ChecksumIEEE(want[4:]); binary.BigEndian.Uint32(sum) != crc {
+		t.Errorf("checksum = % x, want %08x", sum, crc)
+	}
+}
+
+func TestDecodeVersion1(t *testing.T) {
+	data := []byte{0xDE, 0xAD, 0xBE, 0xEF, // start sentinel
+		0x00, 0x02, // numLEDs
+		0x01, 0x01, 0xff, 0x00, 0x00, 0xFF,
+		0x01, 0x02, 0x00, 0xff, 0xff, 0xFF,
+	}
+	f, err := NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Header.Version != Version1 || len(f.LEDs) != 2 || f.LEDs[1].Green != 0xff {
+		t.Errorf("unexpected frame %+v", f)
+	}
+}
+
+func TestDecodeUnsupportedVersion(t *testing.T) {
+	data := encodeFrames(t, makeFrame(1))
+	data[4] = 9
+	_, err := NewDecoder(bytes.NewReader(data)).Decode()
+	if verr, ok := err.(*UnsupportedVersionError); !ok || verr.Version != 9 {
+		t.Errorf("expected *UnsupportedVersionError for version 9, got %v", err)
+	}
+
+	dec := NewDecoder(bytes.NewReader(encodeFrames(t, makeFrame(1))))
+	dec.MaxVersion = Version1
+	if _, err := dec.Decode(); err == nil {
+		t.Error("expected Version2 frame to be rejected by a Version1 decoder")
+	}
+}
+
+func TestDecodeCountsDroppedFrames(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for n := 0; n < 6; n++ {
+		mark := buf.Len()
+		if err := enc.Encode(makeFrame(1)); err != nil {
+			t.Fatal(err)
+		}
+		if n == 2 || n == 3 {
+			buf.Truncate(mark) // lose frames 2 and 3 in transit
+		}
+	}
+
+	dec := NewDecoder(&buf)
+	for {
+		if _, err := dec.Decode(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := dec.Stats(); stats.Frames != 4 || stats.Dropped != 2 {
+		t.Errorf("Stats() = %+v, want 4 frames and 2 dropped", stats)
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	frames := []Frame{makeFrame(3), makeFrame(0), makeFrame(800)}
+	dec := NewDecoder(bytes.NewReader(encodeFrames(t, frames...)))
+
+	for i, want := range frames {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if got.Header.Version != CurrentVersion || got.Header.Sequence != uint32(i) {
+			t.Errorf("frame %d: unexpected header %+v", i, got.Header)
+		}
+		if int(got.Header.NumLEDs) != len(want.LEDs) || len(got.LEDs) != len(want.LEDs) {
+			t.Fatalf("frame %d: got %d LEDs, want %d", i, len(got.LEDs), len(want.LEDs))
+		}
+		if len(want.LEDs) > 0 && !reflect.DeepEqual(got.LEDs, want.LEDs) {
+			t.Errorf("frame %d: LEDs differ", i)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF after last frame, got %v", err)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	data := encodeFrames(t, makeFrame(2))
+	tests := []struct {
+		name string
+		len  int
+		part string
+	}{
+		{"before header", 4, "header"},
+		{"in header", 5, "header"},
+		{"in LEDs", len(data) - 5, "LEDs"},
+		{"in checksum", len(data) - 1, "checksum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDecoder(bytes.NewReader(data[:tt.len])).Decode()
+			terr, ok := err.(*TruncatedError)
+			if !ok {
+				t.Fatalf("expected *TruncatedError, got %v", err)
+			}
+			if terr.Part != tt.part {
+				t.Errorf("truncated part = %q, want %q", terr.Part, tt.part)
+			}
+		})
+	}
+}
+
+func TestDecodeOversize(t *testing.T) {
+	dec := NewDecoder(bytes.NewReader(encodeFrames(t, makeFrame(10), makeFrame(2))))
+	dec.MaxLEDs = 5
+
+	if _, err := dec.Decode(); err == nil {
+		t.Fatal("expected an error for oversize frame")
+	} else if oerr, ok := err.(*OversizeError); !ok || oerr.NumLEDs != 10 {
+		t.Fatalf("expected *OversizeError for 10 LEDs, got %v", err)
+	}
+	// the oversize frame is consumed so the next one is still readable
+	if f, err := dec.Decode(); err != nil || len(f.LEDs) != 2 {
+		t.Errorf("expected following frame of 2 LEDs, got %d, %v", len(f.LEDs), err)
+	}
+}
+
+func TestEncodeOversize(t *testing.T) {
+	err := NewEncoder(ioutil.Discard).Encode(makeFrame(MaxLEDs + 1))
+	if _, ok := err.(*OversizeError); !ok {
+		t.Errorf("expected *OversizeError, got %v", err)
+	}
+}
+
+func TestDecodeSkipsToSentinel(t *testing.T) {
+	data := append([]byte{0x01, 0xDE, 0xAD, 0x00}, encodeFrames(t, makeFrame(1))...)
+	data = append(data, 0xDE, 0xAD) // trailing partial sentinel
+	dec := NewDecoder(bytes.NewReader(data))
+
+	if f, err := dec.Decode(); err != nil || len(f.LEDs) != 1 {
+		t.Fatalf("expected frame of 1 LED, got %d, %v", len(f.LEDs), err)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	if stats := dec.Stats(); stats.Skipped != 6 {
+		t.Errorf("Stats().Skipped = %d, want 6", stats.Skipped)
+	}
+}
+
+func TestDecodeDropsCorruptFrames(t *testing.T) {
+	first := encodeFrames(t, makeFrame(3))
+	tests := []struct {
+		name   string
+		offset int // byte of the middle frame to corrupt
+	}{
+		{"LED data", len(first) - 6},
+		{"timestamp", 15},
+		{"encoding", 6},     // names an encoding that doesn't exist
+		{"pixel format", 7}, // names a pixel format that doesn't exist
+		{"LED count", 8},    // claims far more LEDs, swallowing the next frame
+		{"checksum", len(first) - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodeFrames(t, makeFrame(3), makeFrame(3), makeFrame(3))
+			data[len(first)+tt.offset] ^= 0x40
+			dec := NewDecoder(bytes.NewReader(data))
+
+			var sequences []uint32
+			for {
+				f, err := dec.Decode()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				sequences = append(sequences, f.Header.Sequence)
+			}
+			if !reflect.DeepEqual(sequences, []uint32{0, 2}) {
+				t.Errorf("decoded sequences %v, want [0 2]", sequences)
+			}
+			if stats := dec.Stats(); stats.Corrupt != 1 || stats.Dropped != 1 {
+				t.Errorf("Stats() = %+v, want 1 corrupt and 1 dropped", stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package frame
+
+import "time"
+
+// DeltaEncoder writes a key frame every KeyInterval frames and, in between,
+// delta frames holding only the LEDs that changed since the previous frame.
+type DeltaEncoder struct {
+	enc      *Encoder
+	sent     map[Key]LEDInfo
+	sinceKey int
+	sentAt   uint32 // timestamp of the last frame written
+
+	// KeyInterval how many frames apart key frames are sent; 1 sends only key frames
+	KeyInterval int
+	// Keepalive when set, frames that change nothing are not written unless
+	// Keepalive has passed since the last frame, going by their timestamps; a key
+	// frame is written then so receivers that joined late can catch up.  0 writes
+	// every frame.
+	Keepalive time.Duration
+}
+
+// NewDeltaEncoder returns a DeltaEncoder writing through enc
+func NewDeltaEncoder(enc *Encoder, keyInterval int) *DeltaEncoder {
+	return &DeltaEncoder{enc: enc, KeyInterval: keyInterval}
+}
+
+// Encode writes the full display f as either a key frame or a delta frame.
+// LEDs that were sent before but are missing from f are sent as off.
+func (d *DeltaEncoder) Encode(f Frame) error {
+	f.Header.Flags &^= FlagDelta
+	f = d.enc.prepare(f)
+
+	current := make(map[Key]LEDInfo, len(f.LEDs))
+	for _, led := range f.LEDs {
+		current[Key{led.Row, led.Column}] = led
+	}
+
+	if d.sent != nil && d.Keepalive > 0 && unchanged(d.sent, current) {
+		if time.Duration(f.Header.Timestamp-d.sentAt)*time.Millisecond < d.Keepalive {
+			return nil
+		}
+		return d.send(f, current, 0)
+	}
+
+	if d.sent == nil || d.sinceKey+1 >= d.KeyInterval || d.enc.Version == Version1 {
+		return d.send(f, current, 0)
+	}
+
+	delta := Frame{Header: f.Header}
+	delta.Header.Flags |= FlagDelta
+	for _, led := range f.LEDs {
+		if sent, ok := d.sent[Key{led.Row, led.Column}]; !ok || sent != led {
+			delta.LEDs = append(delta.LEDs, led)
+		}
+	}
+	for key := range d.sent {
+		if _, ok := current[key]; !ok {
+			delta.LEDs = append(delta.LEDs, LEDInfo{Row: key.Row, Column: key.Column})
+		}
+	}
+	return d.send(delta, current, d.sinceKey+1)
+}
+
+// KeyFrame forces the next frame to be a key frame, e.g. when a new receiver connects
+func (d *DeltaEncoder) KeyFrame() {
+	d.sent = nil
+}
+
+func (d *DeltaEncoder) send(f Frame, current map[Key]LEDInfo, sinceKey int) error {
+	if err := d.enc.write(f); err != nil {
+		return err
+	}
+	d.sent, d.sinceKey, d.sentAt = current, sinceKey, f.Header.Timestamp
+	return nil
+}
+
+// unchanged reports whether the display in current is the one last sent
+func unchanged(sent, current map[Key]LEDInfo) bool {
+	if len(sent) != len(current) {
+		return false
+	}
+	for key, led := range current {
+		if s, ok := sent[key]; !ok || s != led {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package frame
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func decodeAll(t *testing.T, r io.Reader) []Frame {
+	var frames []Frame
+	dec := NewDecoder(r)
+	for {
+		f, err := dec.Decode()
+		if err == io.EOF {
+			return frames
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, f)
+	}
+}
+
+func TestDeltaEncoderRebuildsState(t *testing.T) {
+	displays := []Frame{makeFrame(6), makeFrame(6), makeFrame(6), makeFrame(6), makeFrame(4)}
+	displays[1].LEDs[2].Blue = 0x10
+	displays[2].LEDs[2].Blue = 0x10
+	displays[3].LEDs[2].Blue = 0x10
+	displays[3].LEDs[5].Green = 0x20
+
+	var buf bytes.Buffer
+	enc := NewDeltaEncoder(NewEncoder(&buf), 3)
+	for _, f := range displays {
+		if err := enc.Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	frames := decodeAll(t, &buf)
+	wantKey := []bool{true, false, false, true, false}
+	wantLEDs := []int{6, 1, 0, 6, 3}
+	state := NewState()
+	for i, f := range frames {
+		if f.Header.IsKeyFrame() != wantKey[i] || len(f.LEDs) != wantLEDs[i] {
+			t.Errorf("frame %d: key frame %t with %d LEDs, want %t with %d",
+				i, f.Header.IsKeyFrame(), len(f.LEDs), wantKey[i], wantLEDs[i])
+		}
+		state.Apply(f)
+		got := []LEDInfo{}
+		for _, led := range state.LEDs() {
+			if led != (LEDInfo{Row: led.Row, Column: led.Column}) {
+				got = append(got, led)
+			}
+		}
+		if !state.Synced() || !reflect.DeepEqual(got, displays[i].LEDs) {
+			t.Errorf("frame %d: state %v, want %v", i, got, displays[i].LEDs)
+		}
+	}
+}
+
+func TestStateUnsyncedAfterLostDelta(t *testing.T) {
+	state := NewState()
+	delta := Frame{Header: Header{Flags: FlagDelta, Sequence: 1}}
+	state.Apply(delta)
+	if state.Synced() {
+		t.Error("expected unsynced state before first key frame")
+	}
+
+	state.Apply(Frame{Header: Header{Sequence: 2}})
+	state.Apply(Frame{Header: Header{Flags: FlagDelta, Sequence: 3}})
+	if !state.Synced() {
+		t.Error("expected synced state after key frame and following delta")
+	}
+
+	state.Apply(Frame{Header: Header{Flags: FlagDelta, Sequence: 5}})
+	if state.Synced() {
+		t.Error("expected unsynced state after a missing delta")
+	}
+}
+
+func TestDeltaEncoderKeepalive(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewDeltaEncoder(NewEncoder(&buf), 30)
+	enc.Keepalive = time.Second
+	changed := makeFrame(6)
+	changed.LEDs[1].Red = 0x40
+	// an idle display at 10 frames a second, changing once
+	for ms := uint32(0); ms <= 2500; ms += 100 {
+		f := makeFrame(6)
+		if ms == 300 {
+			f = changed
+		}
+		if ms > 300 && ms < 500 {
+			f = changed // unchanged since 300
+		}
+		f.Header.Timestamp = ms
+		if err := enc.Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	frames := decodeAll(t, &buf)
+	wantAt := []uint32{0, 300, 500, 1500, 2500}
+	wantKey := []bool{true, false, false, true, true}
+	if len(frames) != len(wantAt) {
+		t.Fatalf("%d frames written, want %d", len(frames), len(wantAt))
+	}
+	for i, f := range frames {
+		if f.Header.Timestamp != wantAt[i] || f.Header.IsKeyFrame() != wantKey[i] {
+			t.Errorf("frame %d at %dms key %t, want at %dms key %t", i, f.Header.Timestamp, f.Header.IsKeyFrame(), wantAt[i], wantKey[i])
+		}
+		if f.Header.Sequence != uint32(i) {
+			t.Errorf("frame %d has sequence %d; skipped frames mustn't leave gaps", i, f.Header.Sequence)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Encoder writes whole frames to an underlying writer
+type Encoder struct {
+	w        io.Writer
+	buf      bytes.Buffer
+	sequence uint32
+
+	// Version the protocol version frames are written with; Version1 for older receivers
+	Version uint8
+	// Encoding the payload encoding for key frames; delta and Version1 frames always use EncodingLEDs
+	Encoding uint8
+	// PixelFormat how the color of each LED is sent; Version1 frames only carry PixelRGBBrightness
+	PixelFormat uint8
+	// WideCoordinates always send 16 bit rows and columns; otherwise they are only
+	// sent when a frame has an LED beyond MaxNarrowCoordinate
+	WideCoordinates bool
+	// Calibration when set corrects the colors of every frame as it is written
+	Calibration *Calibration
+	// PowerLimit when set dims key frames whose estimated draw is over budget;
+	// a DeltaEncoder limits the whole display before working out each delta
+	PowerLimit *PowerLimit
+
+	power PowerReport
+}
+
+// NewEncoder returns an Encoder that writes CurrentVersion frames to w,
+// choosing the smallest payload encoding for each frame
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, Version: CurrentVersion, Encoding: EncodingAuto}
+}
+
+// Encode writes the sentinel, header and LED records of f as a single write.
+// Header.Version, Encoding, NumLEDs and Sequence are filled in by the encoder;
+// Flags, Timestamp and Duration are taken from f as given.  Version1 frames carry
+// only NumLEDs; later versions end with a CRC32 of everything after the sentinel.
+// Calibration and PowerLimit, when set, are applied to f first.
+func (e *Encoder) Encode(f Frame) error {
+	return e.write(e.prepare(f))
+}
+
+// Power returns how the last key frame was limited
+func (e *Encoder) Power() PowerReport {
+	return e.power
+}
+
+// prepare calibrates f and keeps it within the power budget
+func (e *Encoder) prepare(f Frame) Frame {
+	if e.Calibration != nil {
+		f = e.Calibration.ApplyFrame(f)
+	}
+	if e.PowerLimit != nil && f.Header.IsKeyFrame() {
+		f, e.power = e.PowerLimit.Limit(f)
+	}
+	return f
+}
+
+// write encodes f as given
+func (e *Encoder) write(f Frame) error {
+	if len(f.LEDs) > MaxLEDs {
+		return &OversizeError{NumLEDs: len(f.LEDs), Max: MaxLEDs}
+	}
+	f.Header.Flags &^= FlagWideCoordinates
+	if e.WideCoordinates || wideCoordinates(f.LEDs) {
+		if e.Version == Version1 {
+			return ErrCoordinateRange
+		}
+		f.Header.Flags |= FlagWideCoordinates
+	}
+	if e.Version == Version1 && e.PixelFormat != PixelRGBBrightness {
+		return &UnsupportedPixelFormatError{Format: e.PixelFormat}
+	}
+	f.Header.PixelFormat = e.PixelFormat
+	encoding, numLEDs, payload, err := e.encodePayload(f)
+	if err != nil {
+		return err
+	}
+	f.Header.Version = e.Version
+	f.Header.Encoding = encoding
+	f.Header.NumLEDs = uint16(numLEDs)
+	f.Header.Sequence = e.sequence
+
+	e.buf.Reset()
+	switch e.Version {
+	case Version1:
+		if f.Header.Flags != 0 {
+			return ErrVersion1Flags
+		}
+		binary.Write(&e.buf, binary.BigEndian, StartSentinel)
+		binary.Write(&e.buf, binary.BigEndian, f.Header.NumLEDs)
+	case Version2:
+		binary.Write(&e.buf, binary.BigEndian, VersionedSentinel)
+		e.buf.WriteByte(e.Version)
+		binary.Write(&e.buf, binary.BigEndian, headerV2{
+			Flags:       f.Header.Flags,
+			Encoding:    f.Header.Encoding,
+			PixelFormat: f.Header.PixelFormat,
+			NumLEDs:     f.Header.NumLEDs,
+			Sequence:    f.Header.Sequence,
+			Timestamp:   f.Header.Timestamp,
+			Duration:    f.Header.Duration,
+		})
+	default:
+		return &UnsupportedVersionError{Version: e.Version}
+	}
+	e.buf.Write(payload)
+	if e.Version != Version1 {
+		binary.Write(&e.buf, binary.BigEndian, crc32.ChecksumIEEE(e.buf.Bytes()[4:]))
+	}
+
+	if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+		return err
+	}
+	e.sequence++
+	return nil
+}
+
+// encodePayload encodes the LEDs of f, returning the encoding used and how many
+// LEDs the payload describes.  Dense and RLE payloads cover every cell of the grid.
+func (e *Encoder) encodePayload(f Frame) (uint8, int, []byte, error) {
+	codec, err := pixelCodec(f.Header.PixelFormat)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	encoding := e.Encoding
+	if e.Version == Version1 || !f.Header.IsKeyFrame() {
+		encoding = EncodingLEDs
+	}
+
+	leds := appendLEDs(nil, f.LEDs, f.Header.Flags&FlagWideCoordinates != 0, codec)
+	if encoding == EncodingLEDs {
+		return EncodingLEDs, len(f.LEDs), leds, nil
+	}
+
+	rows, columns := gridSize(f.LEDs)
+	if rows*columns > MaxLEDs {
+		if encoding == EncodingAuto {
+			return EncodingLEDs, len(f.LEDs), leds, nil
+		}
+		return 0, 0, nil, &OversizeError{NumLEDs: rows * columns, Max: MaxLEDs}
+	}
+	pixels := gridOf(f.LEDs, rows, columns)
+
+	switch encoding {
+	case EncodingDense:
+		return EncodingDense, len(pixels), appendDense(nil, rows, columns, pixels, codec), nil
+	case EncodingRLE:
+		return EncodingRLE, len(pixels), appendRLE(nil, rows, columns, pixels, codec), nil
+	case EncodingAuto:
+		encoding, numLEDs, payload := EncodingLEDs, len(f.LEDs), leds
+		if dense := appendDense(nil, rows, columns, pixels, codec); len(dense) < len(payload) {
+			encoding, numLEDs, payload = EncodingDense, len(pixels), dense
+		}
+		if rle := appendRLE(nil, rows, columns, pixels, codec); len(rle) < len(payload) {
+			encoding, numLEDs, payload = EncodingRLE, len(pixels), rle
+		}
+		return encoding, numLEDs, payload, nil
+	}
+	return 0, 0, nil, &UnsupportedEncodingError{Encoding: encoding}
+}
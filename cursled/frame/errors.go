@@ -0,0 +1,70 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCoordinateRange is returned when encoding a Version1 frame with an LED beyond MaxNarrowCoordinate
+var ErrCoordinateRange = errors.New("frame: Version1 frames cannot address rows or columns beyond 255")
+
+// ErrVersion1Flags is returned when encoding a Version1 frame that has header flags set
+var ErrVersion1Flags = errors.New("frame: Version1 frames cannot carry header flags")
+
+// TruncatedError reports a frame whose stream ended part way through
+type TruncatedError struct {
+	Part string // which part of the frame was being read
+	Want int    // bytes expected for that part
+	Got  int    // bytes actually read
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("frame: truncated %s, read %d of %d bytes", e.Part, e.Got, e.Want)
+}
+
+// OversizeError reports a frame describing more LEDs than allowed
+type OversizeError struct {
+	NumLEDs int
+	Max     int
+}
+
+func (e *OversizeError) Error() string {
+	return fmt.Sprintf("frame: %d LEDs exceeds maximum of %d", e.NumLEDs, e.Max)
+}
+
+// UnsupportedVersionError reports a frame using a protocol version the reader or writer does not accept
+type UnsupportedVersionError struct {
+	Version uint8
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("frame: unsupported protocol version %d", e.Version)
+}
+
+// UnsupportedEncodingError reports a frame using a payload encoding the reader or writer does not know
+type UnsupportedEncodingError struct {
+	Encoding uint8
+}
+
+func (e *UnsupportedEncodingError) Error() string {
+	return fmt.Sprintf("frame: unsupported payload encoding %d", e.Encoding)
+}
+
+// UnsupportedPixelFormatError reports a frame using a pixel format the reader or writer does not know
+type UnsupportedPixelFormatError struct {
+	Format uint8
+}
+
+func (e *UnsupportedPixelFormatError) Error() string {
+	return fmt.Sprintf("frame: unsupported pixel format %d", e.Format)
+}
+
+// CorruptFrameError reports a frame of a recording that failed its checksum; the
+// Reader moves on to the frame after it
+type CorruptFrameError struct {
+	Frame int
+}
+
+func (e *CorruptFrameError) Error() string {
+	return fmt.Sprintf("frame: frame %d of the recording is corrupt", e.Frame)
+}
@@ -3,17 +3,77 @@ package frame
 // StartSentinel The sentinel to indicate start of data transmission
 const StartSentinel uint32 = 0xDEADBEEF
 
+// VersionedSentinel The sentinel for frames whose header begins with a protocol version.
+// Frames starting with StartSentinel are always Version1.
+const VersionedSentinel uint32 = 0xDEADC0DE
+
+// Protocol versions understood by this package
+const (
+	Version1 uint8 = 1 // sentinel, LED count and LED records only
+	Version2 uint8 = 2 // adds sequence number, timestamp, duration and a CRC32 trailer
+
+	CurrentVersion = Version2
+)
+
+// Header flags
+const (
+	// FlagDelta the frame holds only the LEDs changed since the previous frame
+	// rather than the whole display (a key frame)
+	FlagDelta uint8 = 1 << iota
+	// FlagWideCoordinates LED records carry 16 bit rows and columns rather than 8 bit
+	FlagWideCoordinates
+)
+
+// MaxNarrowCoordinate the largest row or column an LED record without FlagWideCoordinates can address
+const MaxNarrowCoordinate = 1<<8 - 1
+
+// MaxWideCoordinate the largest row or column an LED record with FlagWideCoordinates can address
+const MaxWideCoordinate = 1<<16 - 1
+
+// MaxLEDs The most LED records that Header.NumLEDs can describe
+const MaxLEDs = 1<<16 - 1
+
 // Header for the data transmission, holding fields applicable for this logical 'frame'
 type Header struct {
-	NumLEDs uint16
+	Version     uint8
+	Flags       uint8
+	Encoding    uint8 // how the LEDs are laid out in the payload; one of the Encoding constants
+	PixelFormat uint8 // how the color of each LED is sent; one of the Pixel constants
+	NumLEDs     uint16
+	Sequence    uint32 // increases by one for every frame sent
+	Timestamp   uint32 // presentation time in milliseconds since the start of the stream
+	Duration    uint16 // milliseconds the frame should be shown for; 0 until the next frame
 }
 
-// LEDInfo The data portion of the frame
+// headerV2 the wire layout of a Version2 header following its version byte
+type headerV2 struct {
+	Flags       uint8
+	Encoding    uint8
+	PixelFormat uint8
+	NumLEDs     uint16
+	Sequence    uint32
+	Timestamp   uint32
+	Duration    uint16
+}
+
+// IsKeyFrame reports whether the frame describes the whole display
+func (h Header) IsKeyFrame() bool {
+	return h.Flags&FlagDelta == 0
+}
+
+// LEDInfo The data portion of the frame.  Rows and columns are sent as 8 bits
+// unless the frame has FlagWideCoordinates.
 type LEDInfo struct {
-	Row        uint8
-	Column     uint8
+	Row        uint16
+	Column     uint16
 	Red        uint8
 	Green      uint8
 	Blue       uint8
 	Brightness uint8
 }
+
+// Frame A logical frame; the header followed by the LEDs it describes
+type Frame struct {
+	Header Header
+	LEDs   []LEDInfo
+}
@@ -0,0 +1,125 @@
+package frame
+
+import "encoding/binary"
+
+// Payload encodings, recorded in Header.Encoding
+const (
+	EncodingLEDs  uint8 = iota // a record per LED holding its row, column and color
+	EncodingDense              // grid size, then every pixel of the grid in row-major order
+	EncodingRLE                // grid size, then runs of identical pixels in row-major order
+
+	// EncodingAuto tells an Encoder to use whichever encoding is smallest for each frame
+	EncodingAuto uint8 = 0xFF
+)
+
+// maxRun the longest run of pixels a single RLE entry can hold
+const maxRun = 255
+
+// gridSize the smallest grid holding all of leds
+func gridSize(leds []LEDInfo) (rows, columns int) {
+	for _, led := range leds {
+		if int(led.Row) >= rows {
+			rows = int(led.Row) + 1
+		}
+		if int(led.Column) >= columns {
+			columns = int(led.Column) + 1
+		}
+	}
+	return rows, columns
+}
+
+// gridOf lays leds out in row-major order on a grid of the given size.
+// Cells without an LED are off.
+func gridOf(leds []LEDInfo, rows, columns int) []LEDInfo {
+	pixels := make([]LEDInfo, rows*columns)
+	for i := range pixels {
+		pixels[i].Row, pixels[i].Column = uint16(i/columns), uint16(i%columns)
+	}
+	for _, led := range leds {
+		pixels[int(led.Row)*columns+int(led.Column)] = led
+	}
+	return pixels
+}
+
+// coordinateSize bytes of row and column at the start of each LED record
+func coordinateSize(wide bool) int {
+	if wide {
+		return 4
+	}
+	return 2
+}
+
+// wideCoordinates reports whether any LED needs more than 8 bits to address
+func wideCoordinates(leds []LEDInfo) bool {
+	for _, led := range leds {
+		if led.Row > MaxNarrowCoordinate || led.Column > MaxNarrowCoordinate {
+			return true
+		}
+	}
+	return false
+}
+
+func appendLEDs(buf []byte, leds []LEDInfo, wide bool, codec PixelCodec) []byte {
+	for _, led := range leds {
+		if wide {
+			buf = append(buf, byte(led.Row>>8), byte(led.Row), byte(led.Column>>8), byte(led.Column))
+		} else {
+			buf = append(buf, byte(led.Row), byte(led.Column))
+		}
+		buf = appendPixel(buf, led, codec)
+	}
+	return buf
+}
+
+// ledRecord decodes the LED record at the start of buf
+func ledRecord(buf []byte, wide bool, codec PixelCodec) LEDInfo {
+	led := LEDInfo{}
+	if wide {
+		led.Row, led.Column = binary.BigEndian.Uint16(buf), binary.BigEndian.Uint16(buf[2:])
+	} else {
+		led.Row, led.Column = uint16(buf[0]), uint16(buf[1])
+	}
+	codec.Get(buf[coordinateSize(wide):], &led)
+	return led
+}
+
+func appendGridSize(buf []byte, rows, columns int) []byte {
+	buf = append(buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint16(buf[len(buf)-4:], uint16(rows))
+	binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(columns))
+	return buf
+}
+
+func appendPixel(buf []byte, led LEDInfo, codec PixelCodec) []byte {
+	start := len(buf)
+	for n := codec.Size(); n > 0; n-- {
+		buf = append(buf, 0)
+	}
+	codec.Put(buf[start:], led)
+	return buf
+}
+
+func appendDense(buf []byte, rows, columns int, pixels []LEDInfo, codec PixelCodec) []byte {
+	buf = appendGridSize(buf, rows, columns)
+	for _, pixel := range pixels {
+		buf = appendPixel(buf, pixel, codec)
+	}
+	return buf
+}
+
+func appendRLE(buf []byte, rows, columns int, pixels []LEDInfo, codec PixelCodec) []byte {
+	buf = appendGridSize(buf, rows, columns)
+	for start := 0; start < len(pixels); {
+		run := 1
+		for start+run < len(pixels) && run < maxRun && samePixel(pixels[start], pixels[start+run]) {
+			run++
+		}
+		buf = appendPixel(append(buf, uint8(run)), pixels[start], codec)
+		start += run
+	}
+	return buf
+}
+
+func samePixel(a, b LEDInfo) bool {
+	return a.Red == b.Red && a.Green == b.Green && a.Blue == b.Blue && a.Brightness == b.Brightness
+}
@@ -0,0 +1,167 @@
+package frame
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// makeBanner a 40x20 grid, black apart from one red row
+func makeBanner() Frame {
+	f := Frame{}
+	for r := uint16(0); r < 40; r++ {
+		for c := uint16(0); c < 20; c++ {
+			led := LEDInfo{Row: r, Column: c}
+			if r == 10 {
+				led.Red, led.Brightness = 0xFF, 0x80
+			}
+			f.LEDs = append(f.LEDs, led)
+		}
+	}
+	return f
+}
+
+func TestEncodingsRoundTrip(t *testing.T) {
+	sparse := Frame{LEDs: []LEDInfo{{Row: 1, Column: 2, Green: 9, Brightness: 1}}}
+	sparseGrid := make([]LEDInfo, 6)
+	for i := range sparseGrid {
+		sparseGrid[i].Row, sparseGrid[i].Column = uint16(i/3), uint16(i%3)
+	}
+	sparseGrid[5] = sparse.LEDs[0]
+
+	tests := []struct {
+		name     string
+		encoding uint8
+		f        Frame
+		want     []LEDInfo
+	}{
+		{"LEDs", EncodingLEDs, sparse, sparse.LEDs},
+		{"dense", EncodingDense, sparse, sparseGrid},
+		{"RLE", EncodingRLE, sparse, sparseGrid},
+		{"dense banner", EncodingDense, makeBanner(), makeBanner().LEDs},
+		{"RLE banner", EncodingRLE, makeBanner(), makeBanner().LEDs},
+		{"RLE empty", EncodingRLE, Frame{}, []LEDInfo{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.Encoding = tt.encoding
+			if err := enc.Encode(tt.f); err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewDecoder(&buf).Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Header.Encoding != tt.encoding {
+				t.Errorf("encoding = %d, want %d", got.Header.Encoding, tt.encoding)
+			}
+			if !reflect.DeepEqual(got.LEDs, tt.want) {
+				t.Errorf("LEDs = %v, want %v", got.LEDs, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodingAutoPicksSmallest(t *testing.T) {
+	full := makeFrame(800)
+	for i := range full.LEDs {
+		full.LEDs[i].Green = uint8(i * 7)
+	}
+	tests := []struct {
+		name string
+		f    Frame
+		want uint8
+		max  int
+	}{
+		{"few LEDs", Frame{LEDs: []LEDInfo{{Row: 39, Column: 19, Red: 1}}}, EncodingLEDs, 6},
+		{"varied full panel", full, EncodingDense, 4 + 800*4},
+		{"mostly uniform panel", makeBanner(), EncodingRLE, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewEncoder(&buf).Encode(tt.f); err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewDecoder(bytes.NewReader(buf.Bytes())).Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Header.Encoding != tt.want {
+				t.Errorf("encoding = %d, want %d", got.Header.Encoding, tt.want)
+			}
+			if payload := buf.Len() - 4 - 1 - 15 - 4; payload > tt.max {
+				t.Errorf("payload of %d bytes, want at most %d", payload, tt.max)
+			}
+		})
+	}
+}
+
+func TestDeltaFramesUseLEDs(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Encoding = EncodingDense
+	f := makeBanner()
+	f.Header.Flags = FlagDelta
+	if err := enc.Encode(f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Encoding != EncodingLEDs {
+		t.Errorf("delta frame encoding = %d, want EncodingLEDs", got.Header.Encoding)
+	}
+}
+
+func TestWideCoordinates(t *testing.T) {
+	wide := Frame{LEDs: []LEDInfo{{Row: 2, Column: 300, Red: 1}, {Row: 511, Column: 7, Blue: 2}}}
+	narrow := Frame{LEDs: []LEDInfo{{Row: 2, Column: 3, Red: 1}}}
+	tests := []struct {
+		name     string
+		f        Frame
+		force    bool
+		wantWide bool
+	}{
+		{"narrow", narrow, false, false},
+		{"forced wide", narrow, true, true},
+		{"needs wide", wide, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.Encoding = EncodingLEDs
+			enc.WideCoordinates = tt.force
+			if err := enc.Encode(tt.f); err != nil {
+				t.Fatal(err)
+			}
+			size := 6
+			if tt.wantWide {
+				size = 8
+			}
+			if want := 4 + 1 + 15 + len(tt.f.LEDs)*size + 4; buf.Len() != want {
+				t.Errorf("encoded %d bytes, want %d", buf.Len(), want)
+			}
+			got, err := NewDecoder(&buf).Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotWide := got.Header.Flags&FlagWideCoordinates != 0; gotWide != tt.wantWide {
+				t.Errorf("wide flag = %t, want %t", gotWide, tt.wantWide)
+			}
+			if !reflect.DeepEqual(got.LEDs, tt.f.LEDs) {
+				t.Errorf("LEDs = %v, want %v", got.LEDs, tt.f.LEDs)
+			}
+		})
+	}
+
+	enc := NewEncoder(&bytes.Buffer{})
+	enc.Version = Version1
+	if err := enc.Encode(wide); err != ErrCoordinateRange {
+		t.Errorf("expected ErrCoordinateRange for a Version1 frame, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package frame
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/aaronbush/go-stuff/cursled/colors"
+)
+
+// Pixel formats, recorded in Header.PixelFormat.  Formats without a brightness
+// byte carry the color already scaled by the LED's brightness.
+const (
+	PixelRGBBrightness uint8 = iota // 8 bit red, green, blue and brightness
+	PixelRGBW                       // 8 bit red, green, blue and white, for RGBW strips such as the SK6812
+	PixelRGB565                     // 5 bit red, 6 bit green and 5 bit blue packed into 16 bits
+	PixelHSV                        // 8 bit hue, saturation and value
+)
+
+// PixelCodec packs the color of an LED into the bytes of one pixel format
+type PixelCodec interface {
+	// Size the number of bytes each pixel takes
+	Size() int
+	// Put writes the color of led to the start of buf
+	Put(buf []byte, led LEDInfo)
+	// Get sets the color of led from the start of buf
+	Get(buf []byte, led *LEDInfo)
+}
+
+var pixelCodecs = map[uint8]PixelCodec{
+	PixelRGBBrightness: rgbBrightnessCodec{},
+	PixelRGBW:          rgbwCodec{},
+	PixelRGB565:        rgb565Codec{},
+	PixelHSV:           hsvCodec{},
+}
+
+// RegisterPixelFormat makes codec available to encoders and decoders as format,
+// replacing any codec already registered for it
+func RegisterPixelFormat(format uint8, codec PixelCodec) {
+	pixelCodecs[format] = codec
+}
+
+// pixelCodec looks up the codec for format
+func pixelCodec(format uint8) (PixelCodec, error) {
+	codec, ok := pixelCodecs[format]
+	if !ok {
+		return nil, &UnsupportedPixelFormatError{Format: format}
+	}
+	return codec, nil
+}
+
+// RGBA the color of the LED scaled by its brightness, which becomes the alpha
+func (l LEDInfo) RGBA() color.RGBA {
+	scale := func(v uint8) uint8 {
+		return uint8((int(v)*int(l.Brightness) + 127) / 255)
+	}
+	return color.RGBA{R: scale(l.Red), G: scale(l.Green), B: scale(l.Blue), A: l.Brightness}
+}
+
+// SetRGBA sets the color and brightness of the LED from c, treating alpha as brightness
+func (l *LEDInfo) SetRGBA(c color.RGBA) {
+	l.Brightness = c.A
+	if c.A == 0 {
+		l.Red, l.Green, l.Blue = 0, 0, 0
+		return
+	}
+	unscale := func(v uint8) uint8 {
+		return uint8(math.Min(255, math.Round(float64(v)*255/float64(c.A))))
+	}
+	l.Red, l.Green, l.Blue = unscale(c.R), unscale(c.G), unscale(c.B)
+}
+
+// RGBAToRGBW moves the white common to all three channels of c into a separate white channel
+func RGBAToRGBW(c color.RGBA) (r, g, b, w uint8) {
+	w = c.R
+	if c.G < w {
+		w = c.G
+	}
+	if c.B < w {
+		w = c.B
+	}
+	return c.R - w, c.G - w, c.B - w, w
+}
+
+// RGBWToRGBA mixes the white channel back into red, green and blue
+func RGBWToRGBA(r, g, b, w uint8) color.RGBA {
+	add := func(v uint8) uint8 {
+		return uint8(math.Min(255, float64(v)+float64(w)))
+	}
+	return color.RGBA{R: add(r), G: add(g), B: add(b), A: 0xFF}
+}
+
+// RGBAToRGB565 packs c into 16 bits, dropping the low bits of each channel
+func RGBAToRGB565(c color.RGBA) uint16 {
+	return uint16(c.R>>3)<<11 | uint16(c.G>>2)<<5 | uint16(c.B>>3)
+}
+
+// RGB565ToRGBA unpacks v, repeating the high bits of each channel into its low bits
+func RGB565ToRGBA(v uint16) color.RGBA {
+	r, g, b := uint8(v>>11&0x1F), uint8(v>>5&0x3F), uint8(v&0x1F)
+	return color.RGBA{R: r<<3 | r>>2, G: g<<2 | g>>4, B: b<<3 | b>>2, A: 0xFF}
+}
+
+// RGBAToHSV converts the color of c to hue, saturation and value, each scaled to 0-255.
+// A hue of 256 would be a full turn of the color wheel.
+func RGBAToHSV(c color.RGBA) (h, s, v uint8) {
+	hsv := colors.FromRGB(color.NRGBA{R: c.R, G: c.G, B: c.B, A: 0xFF})
+	return uint8(int(math.Round(hsv.H*256/360)) % 256), uint8(math.Round(hsv.S * 255)), uint8(math.Round(hsv.V * 255))
+}
+
+// HSVToRGBA converts hue, saturation and value, each scaled to 0-255, to an opaque color
+func HSVToRGBA(h, s, v uint8) color.RGBA {
+	return color.RGBA(colors.HSV{H: float64(h) * 360 / 256, S: float64(s) / 255, V: float64(v) / 255}.RGB())
+}
+
+type rgbBrightnessCodec struct{}
+
+func (rgbBrightnessCodec) Size() int { return 4 }
+
+func (rgbBrightnessCodec) Put(buf []byte, led LEDInfo) {
+	buf[0], buf[1], buf[2], buf[3] = led.Red, led.Green, led.Blue, led.Brightness
+}
+
+func (rgbBrightnessCodec) Get(buf []byte, led *LEDInfo) {
+	led.Red, led.Green, led.Blue, led.Brightness = buf[0], buf[1], buf[2], buf[3]
+}
+
+type rgbwCodec struct{}
+
+func (rgbwCodec) Size() int { return 4 }
+
+func (rgbwCodec) Put(buf []byte, led LEDInfo) {
+	buf[0], buf[1], buf[2], buf[3] = RGBAToRGBW(led.RGBA())
+}
+
+func (rgbwCodec) Get(buf []byte, led *LEDInfo) {
+	led.SetRGBA(RGBWToRGBA(buf[0], buf[1], buf[2], buf[3]))
+}
+
+type rgb565Codec struct{}
+
+func (rgb565Codec) Size() int { return 2 }
+
+func (rgb565Codec) Put(buf []byte, led LEDInfo) {
+	v := RGBAToRGB565(led.RGBA())
+	buf[0], buf[1] = byte(v>>8), byte(v)
+}
+
+func (rgb565Codec) Get(buf []byte, led *LEDInfo) {
+	led.SetRGBA(RGB565ToRGBA(uint16(buf[0])<<8 | uint16(buf[1])))
+}
+
+type hsvCodec struct{}
+
+func (hsvCodec) Size() int { return 3 }
+
+func (hsvCodec) Put(buf []byte, led LEDInfo) {
+	buf[0], buf[1], buf[2] = RGBAToHSV(led.RGBA())
+}
+
+func (hsvCodec) Get(buf []byte, led *LEDInfo) {
+	led.SetRGBA(HSVToRGBA(buf[0], buf[1], buf[2]))
+}
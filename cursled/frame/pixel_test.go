@@ -0,0 +1,135 @@
+package frame
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+func TestLEDInfoRGBA(t *testing.T) {
+	led := LEDInfo{Red: 0xFF, Green: 0x80, Blue: 0x00, Brightness: 0x80}
+	want := color.RGBA{R: 0x80, G: 0x40, B: 0x00, A: 0x80}
+	if got := led.RGBA(); got != want {
+		t.Errorf("RGBA() = %v, want %v", got, want)
+	}
+
+	back := LEDInfo{}
+	back.SetRGBA(want)
+	if back != led {
+		t.Errorf("SetRGBA(%v) = %+v, want %+v", want, back, led)
+	}
+}
+
+func TestRGBW(t *testing.T) {
+	r, g, b, w := RGBAToRGBW(color.RGBA{R: 0xF0, G: 0x80, B: 0x30, A: 0xFF})
+	if r != 0xC0 || g != 0x50 || b != 0x00 || w != 0x30 {
+		t.Errorf("RGBAToRGBW() = %x %x %x %x, want c0 50 0 30", r, g, b, w)
+	}
+	if got := RGBWToRGBA(r, g, b, w); got != (color.RGBA{R: 0xF0, G: 0x80, B: 0x30, A: 0xFF}) {
+		t.Errorf("RGBWToRGBA() = %v", got)
+	}
+}
+
+func TestRGB565(t *testing.T) {
+	tests := []color.RGBA{
+		{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF},
+		{R: 0x00, G: 0x00, B: 0x00, A: 0xFF},
+		{R: 0xF8, G: 0x04, B: 0x08, A: 0xFF},
+	}
+	for _, c := range tests {
+		if got := RGB565ToRGBA(RGBAToRGB565(c)); !near(got, c, 7) {
+			t.Errorf("RGB565 round trip of %v = %v", c, got)
+		}
+	}
+	if v := RGBAToRGB565(color.RGBA{R: 0xFF}); v != 0xF800 {
+		t.Errorf("RGBAToRGB565(red) = %04x, want f800", v)
+	}
+}
+
+func TestHSV(t *testing.T) {
+	tests := []struct {
+		c       color.RGBA
+		h, s, v uint8
+	}{
+		{color.RGBA{R: 0xFF, A: 0xFF}, 0, 0xFF, 0xFF},
+		{color.RGBA{G: 0xFF, A: 0xFF}, 85, 0xFF, 0xFF},
+		{color.RGBA{B: 0xFF, A: 0xFF}, 171, 0xFF, 0xFF},
+		{color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF}, 0, 0, 0x80},
+		{color.RGBA{A: 0xFF}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if h, s, v := RGBAToHSV(tt.c); h != tt.h || s != tt.s || v != tt.v {
+			t.Errorf("RGBAToHSV(%v) = %d %d %d, want %d %d %d", tt.c, h, s, v, tt.h, tt.s, tt.v)
+		}
+		if got := HSVToRGBA(tt.h, tt.s, tt.v); !near(got, tt.c, 2) {
+			t.Errorf("HSVToRGBA(%d, %d, %d) = %v, want %v", tt.h, tt.s, tt.v, got, tt.c)
+		}
+	}
+}
+
+func TestPixelFormatsRoundTrip(t *testing.T) {
+	f := Frame{LEDs: []LEDInfo{
+		{Row: 0, Column: 0, Red: 0xFF, Green: 0xFF, Blue: 0xFF, Brightness: 0xFF},
+		{Row: 0, Column: 1, Red: 0xFF, Green: 0x80, Blue: 0x10, Brightness: 0x80},
+		{Row: 1, Column: 0, Red: 0x00, Green: 0x40, Blue: 0xC0, Brightness: 0xFF},
+	}}
+	tests := []struct {
+		name      string
+		format    uint8
+		size      int
+		tolerance int
+	}{
+		{"RGB brightness", PixelRGBBrightness, 4, 0},
+		{"RGBW", PixelRGBW, 4, 1},
+		{"RGB565", PixelRGB565, 2, 7},
+		{"HSV", PixelHSV, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.Encoding = EncodingLEDs
+			enc.PixelFormat = tt.format
+			if err := enc.Encode(f); err != nil {
+				t.Fatal(err)
+			}
+			if want := 4 + 1 + 15 + len(f.LEDs)*(2+tt.size) + 4; buf.Len() != want {
+				t.Errorf("encoded %d bytes, want %d", buf.Len(), want)
+			}
+			got, err := NewDecoder(&buf).Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Header.PixelFormat != tt.format {
+				t.Errorf("pixel format = %d, want %d", got.Header.PixelFormat, tt.format)
+			}
+			for i, led := range got.LEDs {
+				if !near(led.RGBA(), f.LEDs[i].RGBA(), tt.tolerance) {
+					t.Errorf("LED %d = %v, want %v", i, led.RGBA(), f.LEDs[i].RGBA())
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownPixelFormat(t *testing.T) {
+	enc := NewEncoder(&bytes.Buffer{})
+	enc.PixelFormat = 0x7F
+	if _, ok := enc.Encode(makeFrame(1)).(*UnsupportedPixelFormatError); !ok {
+		t.Error("expected *UnsupportedPixelFormatError")
+	}
+
+	enc.Version, enc.PixelFormat = Version1, PixelRGBW
+	if _, ok := enc.Encode(makeFrame(1)).(*UnsupportedPixelFormatError); !ok {
+		t.Error("expected *UnsupportedPixelFormatError for a Version1 RGBW frame")
+	}
+}
+
+// near reports whether the color channels of a and b differ by at most tolerance
+func near(a, b color.RGBA, tolerance int) bool {
+	diff := func(x, y uint8) bool {
+		d := int(x) - int(y)
+		return d <= tolerance && d >= -tolerance
+	}
+	return diff(a.R, b.R) && diff(a.G, b.G) && diff(a.B, b.B)
+}
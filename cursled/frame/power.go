@@ -0,0 +1,72 @@
+package frame
+
+import (
+	"fmt"
+	"math"
+)
+
+// PowerLimit models the current an LED display draws and keeps frames within
+// what its supply can give by dimming them
+type PowerLimit struct {
+	Red    float64 // milliamps one LED's red channel draws at full color and brightness
+	Green  float64 // milliamps one LED's green channel draws at full color and brightness
+	Blue   float64 // milliamps one LED's blue channel draws at full color and brightness
+	Idle   float64 // milliamps each LED draws even when off
+	Budget float64 // milliamps the supply can give the whole display
+}
+
+// DefaultChannelMilliamps the commonly quoted draw of one WS2812 channel at full brightness
+const DefaultChannelMilliamps = 20
+
+// PowerReport describes how a frame was limited
+type PowerReport struct {
+	Milliamps float64 // estimated draw of the frame as given
+	Scale     float64 // what brightness was multiplied by; 1 when within budget
+}
+
+// Limited reports whether the frame had to be dimmed
+func (r PowerReport) Limited() bool {
+	return r.Scale < 1
+}
+
+func (r PowerReport) String() string {
+	if r.Limited() {
+		return fmt.Sprintf("%.0fmA limited to %.0f%%", r.Milliamps, r.Scale*100)
+	}
+	return fmt.Sprintf("%.0fmA", r.Milliamps)
+}
+
+// Estimate the milliamps f draws, assuming it holds every lit LED of the display
+func (p *PowerLimit) Estimate(f Frame) float64 {
+	idle, lit := p.estimate(f)
+	return idle + lit
+}
+
+// estimate splits the draw of f into the part brightness can't change and the part it can
+func (p *PowerLimit) estimate(f Frame) (idle, lit float64) {
+	for _, led := range f.LEDs {
+		color := float64(led.Red)*p.Red + float64(led.Green)*p.Green + float64(led.Blue)*p.Blue
+		lit += color * float64(led.Brightness) / (255 * 255)
+	}
+	return float64(len(f.LEDs)) * p.Idle, lit
+}
+
+// Limit returns a copy of f whose brightness is scaled down in proportion, if
+// needed, so its estimated draw stays within the budget.  f is left untouched.
+func (p *PowerLimit) Limit(f Frame) (Frame, PowerReport) {
+	idle, lit := p.estimate(f)
+	report := PowerReport{Milliamps: idle + lit, Scale: 1}
+	if report.Milliamps <= p.Budget {
+		return f, report
+	}
+
+	report.Scale = math.Max(0, (p.Budget-idle)/lit)
+	leds := make([]LEDInfo, len(f.LEDs))
+	for i, led := range f.LEDs {
+		// rounding down keeps the dimmed frame within budget
+		led.Brightness = uint8(math.Floor(float64(led.Brightness) * report.Scale))
+		leds[i] = led
+	}
+	f.LEDs = leds
+	return f, report
+}
@@ -0,0 +1,82 @@
+package frame
+
+import (
+	"bytes"
+	"testing"
+)
+
+func whiteFrame(n int, brightness uint8) Frame {
+	f := Frame{}
+	for i := 0; i < n; i++ {
+		f.LEDs = append(f.LEDs, LEDInfo{Row: uint16(i / 20), Column: uint16(i % 20), Red: 0xFF, Green: 0xFF, Blue: 0xFF, Brightness: brightness})
+	}
+	return f
+}
+
+func TestPowerEstimate(t *testing.T) {
+	p := &PowerLimit{Red: 20, Green: 20, Blue: 20, Idle: 1}
+	if got := p.Estimate(whiteFrame(800, 0xFF)); got != 800*61 {
+		t.Errorf("Estimate(full white) = %v, want %v", got, 800*61)
+	}
+	if got := p.Estimate(whiteFrame(10, 0)); got != 10 {
+		t.Errorf("Estimate(off) = %v, want 10", got)
+	}
+	red := Frame{LEDs: []LEDInfo{{Red: 0xFF, Brightness: 0xFF}}}
+	if got := p.Estimate(red); got != 21 {
+		t.Errorf("Estimate(red) = %v, want 21", got)
+	}
+}
+
+func TestPowerLimit(t *testing.T) {
+	p := &PowerLimit{Red: 20, Green: 20, Blue: 20, Idle: 1, Budget: 4000}
+
+	f := whiteFrame(800, 0xFF)
+	limited, report := p.Limit(f)
+	if !report.Limited() {
+		t.Fatal("expected full white to be limited")
+	}
+	if report.Milliamps != 800*61 {
+		t.Errorf("report.Milliamps = %v, want %v", report.Milliamps, 800*61)
+	}
+	if got := p.Estimate(limited); got > p.Budget {
+		t.Errorf("limited frame draws %vmA, over the %vmA budget", got, p.Budget)
+	}
+	if f.LEDs[0].Brightness != 0xFF {
+		t.Error("Limit changed the caller's frame")
+	}
+	if again, report := p.Limit(limited); report.Limited() || again.LEDs[0] != limited.LEDs[0] {
+		t.Errorf("limiting a frame within budget changed it: %+v", report)
+	}
+
+	small := whiteFrame(10, 0xFF)
+	if got, report := p.Limit(small); report.Limited() || got.LEDs[0].Brightness != 0xFF {
+		t.Errorf("frame within budget was dimmed: %+v", report)
+	}
+}
+
+func TestDeltaEncoderPowerLimit(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.PowerLimit = &PowerLimit{Red: 20, Green: 20, Blue: 20, Budget: 1000}
+	delta := NewDeltaEncoder(enc, 10)
+
+	// the second frame only adds LEDs, so it is sent as a delta but still has to
+	// dim the LEDs sent in the first
+	frames := []Frame{whiteFrame(10, 0xFF), whiteFrame(40, 0xFF)}
+	state := NewState()
+	for _, f := range frames {
+		if err := delta.Encode(f); err != nil {
+			t.Fatal(err)
+		}
+		decoded := decodeAll(t, &buf)
+		for _, d := range decoded {
+			state.Apply(d)
+		}
+		if got := enc.PowerLimit.Estimate(Frame{LEDs: state.LEDs()}); got > enc.PowerLimit.Budget {
+			t.Errorf("display draws %vmA after %d LEDs, over budget", got, len(f.LEDs))
+		}
+	}
+	if !enc.Power().Limited() {
+		t.Error("expected Power() to report limiting")
+	}
+}
@@ -0,0 +1,65 @@
+package frame
+
+import "sort"
+
+// Key identifies an LED by its position on the grid
+type Key struct {
+	Row    uint16
+	Column uint16
+}
+
+// State is the full display rebuilt from a stream of key and delta frames
+type State struct {
+	leds         map[Key]LEDInfo
+	lastSequence uint32
+	synced       bool
+}
+
+// NewState returns an empty State that is waiting for its first key frame
+func NewState() *State {
+	return &State{leds: make(map[Key]LEDInfo)}
+}
+
+// Apply updates the display with f.  A key frame replaces the whole display,
+// LEDs it does not mention are off; a delta frame changes only the LEDs it holds.
+func (s *State) Apply(f Frame) {
+	if f.Header.IsKeyFrame() {
+		s.leds = make(map[Key]LEDInfo, len(f.LEDs))
+		s.synced = true
+	} else if f.Header.Sequence != s.lastSequence+1 {
+		// an earlier delta was lost so the display is wrong until the next key frame
+		s.synced = false
+	}
+	s.lastSequence = f.Header.Sequence
+
+	for _, led := range f.LEDs {
+		s.leds[Key{led.Row, led.Column}] = led
+	}
+}
+
+// Synced reports whether the display is known to be complete; false before the
+// first key frame or after a gap in delta frames
+func (s *State) Synced() bool {
+	return s.synced
+}
+
+// Get returns the LED at row and column, if the display has one there
+func (s *State) Get(row, column uint16) (LEDInfo, bool) {
+	led, ok := s.leds[Key{row, column}]
+	return led, ok
+}
+
+// LEDs returns every LED in the display in row-major order
+func (s *State) LEDs() []LEDInfo {
+	leds := make([]LEDInfo, 0, len(s.leds))
+	for _, led := range s.leds {
+		leds = append(leds, led)
+	}
+	sort.Slice(leds, func(i, j int) bool {
+		if leds[i].Row != leds[j].Row {
+			return leds[i].Row < leds[j].Row
+		}
+		return leds[i].Column < leds[j].Column
+	})
+	return leds
+}
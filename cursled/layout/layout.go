@@ -0,0 +1,61 @@
+// Package layout maps the logical grid drawn on to the order the LEDs sit in
+// along a controller's strip
+package layout
+
+// Layout says which cell of the grid each LED along the strip shows
+type Layout interface {
+	// Len the number of LEDs on the strip
+	Len() int
+	// Cell the grid cell the LED at index shows; ok is false for LEDs that show nothing
+	Cell(index int) (row, column int, ok bool)
+}
+
+// Cell a position on the logical grid
+type Cell struct {
+	Row, Column int
+}
+
+// table a Layout worked out ahead of time, one cell per LED
+type table struct {
+	cells []Cell
+	used  []bool
+}
+
+func newTable(n int) *table {
+	return &table{cells: make([]Cell, n), used: make([]bool, n)}
+}
+
+func (t *table) set(index int, cell Cell) {
+	t.cells[index], t.used[index] = cell, true
+}
+
+func (t *table) Len() int {
+	return len(t.cells)
+}
+
+func (t *table) Cell(index int) (int, int, bool) {
+	if index < 0 || index >= len(t.cells) || !t.used[index] {
+		return 0, 0, false
+	}
+	return t.cells[index].Row, t.cells[index].Column, true
+}
+
+// RowMajor the layout of a strip running along each row in turn, left to right
+func RowMajor(rows, columns int) Layout {
+	return rowMajor{rows: rows, columns: columns}
+}
+
+type rowMajor struct {
+	rows, columns int
+}
+
+func (r rowMajor) Len() int {
+	return r.rows * r.columns
+}
+
+func (r rowMajor) Cell(index int) (int, int, bool) {
+	if index < 0 || index >= r.Len() {
+		return 0, 0, false
+	}
+	return index / r.columns, index % r.columns, true
+}
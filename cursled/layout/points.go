@@ -0,0 +1,225 @@
+package layout
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxPoints the most LEDs a coordinate file may place, as many as a frame can hold
+const MaxPoints = 0xFFFF
+
+// Point where an LED physically sits, in any unit; y grows downwards as on screen
+type Point struct {
+	X, Y float64
+}
+
+// Positioned is implemented by layouts that know where each LED physically
+// sits, so previews can draw them there and effects can be worked out at the
+// LED rather than at a grid cell
+type Positioned interface {
+	Layout
+	// Position where the LED at index sits; ok is false for LEDs without one
+	Position(index int) (p Point, ok bool)
+	// Bounds the smallest box holding every LED
+	Bounds() (min, max Point)
+}
+
+// Points the physical position of each LED along a strip, e.g. of a ring or a
+// sculpture, as read from a coordinate file
+type Points struct {
+	points []Point
+	used   []bool
+}
+
+// pointRecord one LED of a JSON coordinate file
+type pointRecord struct {
+	Index *int     `json:"index"`
+	X     *float64 `json:"x"`
+	Y     *float64 `json:"y"`
+}
+
+// LoadPoints reads a coordinate file, JSON when its name ends in .json and CSV otherwise
+func LoadPoints(path string) (*Points, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ReadPointsJSON(file)
+	}
+	return ReadPointsCSV(file)
+}
+
+// ReadPointsCSV reads lines of index,x,y.  A first line that isn't numbers is
+// taken as a header and skipped, as are blank lines and lines starting with #.
+func ReadPointsCSV(r io.Reader) (*Points, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	p := &Points{}
+	for line := 0; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layout: %w", err)
+		}
+		index, err := strconv.Atoi(record[0])
+		if err != nil && line == 0 {
+			continue // header
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layout: bad index %q", record[0])
+		}
+		x, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("layout: LED %d: bad x %q", index, record[1])
+		}
+		y, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("layout: LED %d: bad y %q", index, record[2])
+		}
+		if err := p.set(index, Point{X: x, Y: y}); err != nil {
+			return nil, err
+		}
+	}
+	return p, p.validate()
+}
+
+// ReadPointsJSON reads an array of {"index": 0, "x": 1.5, "y": 2}
+func ReadPointsJSON(r io.Reader) (*Points, error) {
+	var records []pointRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("layout: %w", err)
+	}
+	p := &Points{}
+	for i, record := range records {
+		if record.Index == nil || record.X == nil || record.Y == nil {
+			return nil, fmt.Errorf("layout: LED %d of the file needs an index, x and y", i)
+		}
+		if err := p.set(*record.Index, Point{X: *record.X, Y: *record.Y}); err != nil {
+			return nil, err
+		}
+	}
+	return p, p.validate()
+}
+
+func (p *Points) set(index int, point Point) error {
+	if index < 0 || index >= MaxPoints {
+		return fmt.Errorf("layout: LED index %d is not between 0 and %d", index, MaxPoints-1)
+	}
+	if math.IsNaN(point.X) || math.IsInf(point.X, 0) || math.IsNaN(point.Y) || math.IsInf(point.Y, 0) {
+		return fmt.Errorf("layout: LED %d is not at a finite position", index)
+	}
+	for len(p.points) <= index {
+		p.points = append(p.points, Point{})
+		p.used = append(p.used, false)
+	}
+	if p.used[index] {
+		return fmt.Errorf("layout: LED %d is listed twice", index)
+	}
+	p.points[index], p.used[index] = point, true
+	return nil
+}
+
+func (p *Points) validate() error {
+	if len(p.points) == 0 {
+		return errors.New("layout: no LEDs in the coordinate file")
+	}
+	return nil
+}
+
+// Len the number of LEDs on the strip, up to the highest index placed
+func (p *Points) Len() int {
+	return len(p.points)
+}
+
+// Position where the LED at index sits; ok is false for indexes the file skipped
+func (p *Points) Position(index int) (Point, bool) {
+	if index < 0 || index >= len(p.points) || !p.used[index] {
+		return Point{}, false
+	}
+	return p.points[index], true
+}
+
+// Bounds the smallest box holding every LED
+func (p *Points) Bounds() (min, max Point) {
+	first := true
+	for i, point := range p.points {
+		if !p.used[i] {
+			continue
+		}
+		if first {
+			min, max, first = point, point, false
+			continue
+		}
+		min.X, min.Y = math.Min(min.X, point.X), math.Min(min.Y, point.Y)
+		max.X, max.Y = math.Max(max.X, point.X), math.Max(max.Y, point.Y)
+	}
+	return min, max
+}
+
+// Sample lays a rows x columns grid over the LEDs, scaled to fit their bounds
+// without stretching and centered, and has each LED show the cell it sits in
+func (p *Points) Sample(rows, columns int) (Positioned, error) {
+	if rows < 1 || columns < 1 {
+		return nil, fmt.Errorf("layout: %dx%d grid has no cells", rows, columns)
+	}
+	min, max := p.Bounds()
+	width, height := max.X-min.X, max.Y-min.Y
+	scale := math.Inf(1)
+	if width > 0 {
+		scale = float64(columns) / width
+	}
+	if height > 0 {
+		scale = math.Min(scale, float64(rows)/height)
+	}
+	if math.IsInf(scale, 1) {
+		scale = 0 // every LED at one spot shows the middle cell
+	}
+	offsetX := (float64(columns) - width*scale) / 2
+	offsetY := (float64(rows) - height*scale) / 2
+
+	s := &sampled{Points: p, table: newTable(p.Len())}
+	for i, point := range p.points {
+		if !p.used[i] {
+			continue
+		}
+		column := clamp(int(math.Floor((point.X-min.X)*scale+offsetX)), columns)
+		row := clamp(int(math.Floor((point.Y-min.Y)*scale+offsetY)), rows)
+		s.table.set(i, Cell{Row: row, Column: column})
+	}
+	return s, nil
+}
+
+// clamp keeps n within 0 to size-1; LEDs on the far edge of the bounds land just past the grid
+func clamp(n, size int) int {
+	if n < 0 {
+		return 0
+	}
+	if n >= size {
+		return size - 1
+	}
+	return n
+}
+
+// sampled points with the grid cell each shows worked out
+type sampled struct {
+	*Points
+	*table
+}
+
+func (s *sampled) Len() int {
+	return s.Points.Len()
+}
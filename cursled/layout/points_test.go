@@ -0,0 +1,120 @@
+package layout
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadPoints(t *testing.T) {
+	csv := `index,x,y
+# the ring's first LED is at the top
+0, 0, -1
+2, 1, 0
+
+1, 0.5, -0.5
+`
+	json := `[{"index": 0, "x": 0, "y": -1}, {"index": 2, "x": 1, "y": 0}, {"index": 1, "x": 0.5, "y": -0.5}]`
+	for name, read := range map[string]func() (*Points, error){
+		"csv":  func() (*Points, error) { return ReadPointsCSV(strings.NewReader(csv)) },
+		"json": func() (*Points, error) { return ReadPointsJSON(strings.NewReader(json)) },
+	} {
+		p, err := read()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if p.Len() != 3 {
+			t.Fatalf("%s: Len() = %d, want 3", name, p.Len())
+		}
+		if point, ok := p.Position(1); !ok || point != (Point{X: 0.5, Y: -0.5}) {
+			t.Errorf("%s: Position(1) = %v, %t", name, point, ok)
+		}
+		if min, max := p.Bounds(); min != (Point{X: 0, Y: -1}) || max != (Point{X: 1, Y: 0}) {
+			t.Errorf("%s: Bounds() = %v, %v", name, min, max)
+		}
+	}
+}
+
+func TestReadPointsRejects(t *testing.T) {
+	tests := map[string]string{
+		"no LEDs":       "index,x,y\n",
+		"listed twice":  "0,1,1\n0,2,2\n",
+		"bad index":     "0,1,1\nfirst,1,1\n",
+		"negative":      "-1,1,1\n",
+		"bad x":         "0,left,1\n",
+		"missing y":     "0,1\n",
+		"not finite":    "0,NaN,1\n",
+		"too many LEDs": "65535,1,1\n",
+	}
+	for name, csv := range tests {
+		if _, err := ReadPointsCSV(strings.NewReader(csv)); err == nil {
+			t.Errorf("%s: accepted %q", name, csv)
+		}
+	}
+	if _, err := ReadPointsJSON(strings.NewReader(`[{"index": 0, "x": 1}]`)); err == nil {
+		t.Error("JSON LED without y accepted")
+	}
+}
+
+func TestSample(t *testing.T) {
+	// eight LEDs around a ring on a 9x9 grid
+	p := &Points{}
+	for i := 0; i < 8; i++ {
+		angle := float64(i) * math.Pi / 4
+		p.set(i, Point{X: math.Sin(angle), Y: -math.Cos(angle)})
+	}
+	l, err := p.Sample(9, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]Cell{
+		0: {Row: 0, Column: 4}, // top
+		2: {Row: 4, Column: 8}, // right, on the far edge
+		4: {Row: 8, Column: 4}, // bottom
+		6: {Row: 4, Column: 0}, // left
+		1: {Row: 1, Column: 7},
+	}
+	for index, cell := range want {
+		if row, column, ok := l.Cell(index); !ok || row != cell.Row || column != cell.Column {
+			t.Errorf("LED %d shows (%d, %d), want (%d, %d)", index, row, column, cell.Row, cell.Column)
+		}
+	}
+	if point, ok := l.Position(4); !ok || math.Abs(point.Y-1) > 1e-9 {
+		t.Errorf("Position(4) = %v, %t", point, ok)
+	}
+}
+
+func TestSampleStrip(t *testing.T) {
+	// a straight strip is centered on the rows rather than stretched over them
+	p := &Points{}
+	for i := 0; i < 4; i++ {
+		p.set(i, Point{X: float64(i)})
+	}
+	p.set(5, Point{X: 3}) // LED 4 isn't placed
+	l, err := p.Sample(3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Len() != 6 {
+		t.Fatalf("Len() = %d, want 6", l.Len())
+	}
+	for index, column := range []int{0, 1, 2, 3} {
+		if r, c, ok := l.Cell(index); !ok || r != 1 || c != column {
+			t.Errorf("LED %d shows (%d, %d), want (1, %d)", index, r, c, column)
+		}
+	}
+	if _, _, ok := l.Cell(4); ok {
+		t.Error("LED without a position shows a cell")
+	}
+
+	single := &Points{}
+	single.set(0, Point{X: 5, Y: 5})
+	if l, _ := single.Sample(3, 5); l != nil {
+		if r, c, _ := l.Cell(0); r != 1 || c != 2 {
+			t.Errorf("lone LED shows (%d, %d), want the middle (1, 2)", r, c)
+		}
+	}
+	if _, err := p.Sample(0, 4); err == nil {
+		t.Error("empty grid accepted")
+	}
+}
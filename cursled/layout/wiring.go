@@ -0,0 +1,121 @@
+package layout
+
+import "fmt"
+
+// Wiring describes how a display's LEDs are physically chained: panels of LEDs
+// wired in lines, possibly zig-zag, chained one after another and mounted at an
+// angle.  The zero value of each field is the plain case.
+type Wiring struct {
+	Rows    int // of the logical grid
+	Columns int // of the logical grid
+
+	// Rotation clockwise degrees, 0, 90, 180 or 270, the whole display is mounted at
+	Rotation int
+	// FlipHorizontal mirrors the whole display left to right, before rotating it
+	FlipHorizontal bool
+	// FlipVertical mirrors the whole display top to bottom, before rotating it
+	FlipVertical bool
+
+	// PanelRows how many rows each panel covers on the mounted display; 0 for one panel
+	PanelRows int
+	// PanelColumns how many columns each panel covers on the mounted display; 0 for one panel
+	PanelColumns int
+	// ChainColumnMajor panels are chained down each column of panels rather than along each row
+	ChainColumnMajor bool
+	// ChainSerpentine alternate lines of panels are chained back the other way
+	ChainSerpentine bool
+	// PanelRotations clockwise degrees each panel is mounted at, by its place in the chain
+	PanelRotations []int
+
+	// ColumnMajor the LEDs of a panel run down its columns rather than along its rows
+	ColumnMajor bool
+	// Serpentine alternate lines of LEDs in a panel run back the other way
+	Serpentine bool
+}
+
+// Layout works out the strip index of every cell of the grid
+func (w Wiring) Layout() (Layout, error) {
+	if w.Rows < 1 || w.Columns < 1 {
+		return nil, fmt.Errorf("layout: %dx%d grid has no cells", w.Rows, w.Columns)
+	}
+	if !validRotation(w.Rotation) {
+		return nil, fmt.Errorf("layout: rotation %d is not 0, 90, 180 or 270", w.Rotation)
+	}
+	// the size of the display as mounted
+	_, _, height, width := rotate(0, 0, w.Rows, w.Columns, w.Rotation)
+	panelRows, panelColumns := w.PanelRows, w.PanelColumns
+	if panelRows == 0 {
+		panelRows = height
+	}
+	if panelColumns == 0 {
+		panelColumns = width
+	}
+	if panelRows < 0 || panelColumns < 0 || height%panelRows != 0 || width%panelColumns != 0 {
+		return nil, fmt.Errorf("layout: %dx%d panels don't tile a %dx%d display", panelRows, panelColumns, height, width)
+	}
+	panelsDown, panelsAcross := height/panelRows, width/panelColumns
+	if len(w.PanelRotations) > panelsDown*panelsAcross {
+		return nil, fmt.Errorf("layout: %d panel rotations for %d panels", len(w.PanelRotations), panelsDown*panelsAcross)
+	}
+	for _, rotation := range w.PanelRotations {
+		if !validRotation(rotation) {
+			return nil, fmt.Errorf("layout: panel rotation %d is not 0, 90, 180 or 270", rotation)
+		}
+	}
+
+	t := newTable(w.Rows * w.Columns)
+	for row := 0; row < w.Rows; row++ {
+		for column := 0; column < w.Columns; column++ {
+			r, c := row, column
+			if w.FlipHorizontal {
+				c = w.Columns - 1 - c
+			}
+			if w.FlipVertical {
+				r = w.Rows - 1 - r
+			}
+			r, c, _, _ = rotate(r, c, w.Rows, w.Columns, w.Rotation)
+
+			panel := serpentine(r/panelRows, c/panelColumns, panelsDown, panelsAcross, w.ChainColumnMajor, w.ChainSerpentine)
+			rotation := 0
+			if panel < len(w.PanelRotations) {
+				rotation = w.PanelRotations[panel]
+			}
+			// undo the panel's rotation to find the cell in the panel's own wiring
+			r, c, h, pw := rotate(r%panelRows, c%panelColumns, panelRows, panelColumns, (360-rotation)%360)
+			index := panel*panelRows*panelColumns + serpentine(r, c, h, pw, w.ColumnMajor, w.Serpentine)
+			t.set(index, Cell{Row: row, Column: column})
+		}
+	}
+	return t, nil
+}
+
+func validRotation(degrees int) bool {
+	return degrees == 0 || degrees == 90 || degrees == 180 || degrees == 270
+}
+
+// rotate turns (row, column) of a rows x columns grid clockwise by degrees,
+// returning where it lands and the size of the turned grid
+func rotate(row, column, rows, columns, degrees int) (int, int, int, int) {
+	switch degrees {
+	case 90:
+		return column, rows - 1 - row, columns, rows
+	case 180:
+		return rows - 1 - row, columns - 1 - column, rows, columns
+	case 270:
+		return columns - 1 - column, row, columns, rows
+	}
+	return row, column, rows, columns
+}
+
+// serpentine numbers (row, column) of a rows x columns grid along its rows, or
+// its columns when columnMajor, with alternate lines reversed when zigzag
+func serpentine(row, column, rows, columns int, columnMajor, zigzag bool) int {
+	line, position, length := row, column, columns
+	if columnMajor {
+		line, position, length = column, row, rows
+	}
+	if zigzag && line%2 == 1 {
+		position = length - 1 - position
+	}
+	return line*length + position
+}
@@ -0,0 +1,133 @@
+package layout
+
+import "testing"
+
+// strip lists the cell shown by each LED along the strip as row*columns+column
+func strip(t *testing.T, l Layout, columns int) []int {
+	t.Helper()
+	cells := make([]int, l.Len())
+	for i := range cells {
+		row, column, ok := l.Cell(i)
+		if !ok {
+			t.Fatalf("LED %d shows nothing", i)
+		}
+		cells[i] = row*columns + column
+	}
+	return cells
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWiring(t *testing.T) {
+	// cells of a 3x4 grid are numbered
+	//   0  1  2  3
+	//   4  5  6  7
+	//   8  9 10 11
+	tests := []struct {
+		name   string
+		wiring Wiring
+		want   []int
+	}{
+		{"plain", Wiring{}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+		{"serpentine rows", Wiring{Serpentine: true}, []int{0, 1, 2, 3, 7, 6, 5, 4, 8, 9, 10, 11}},
+		{"serpentine columns", Wiring{ColumnMajor: true, Serpentine: true}, []int{0, 4, 8, 9, 5, 1, 2, 6, 10, 11, 7, 3}},
+		{"flip horizontal", Wiring{FlipHorizontal: true}, []int{3, 2, 1, 0, 7, 6, 5, 4, 11, 10, 9, 8}},
+		{"flip vertical", Wiring{FlipVertical: true}, []int{8, 9, 10, 11, 4, 5, 6, 7, 0, 1, 2, 3}},
+		{"rotate 180", Wiring{Rotation: 180}, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0}},
+		// mounted turned clockwise, the display is 4 rows of 3 with cell 8 at the top left
+		{"rotate 90", Wiring{Rotation: 90}, []int{8, 4, 0, 9, 5, 1, 10, 6, 2, 11, 7, 3}},
+		{"rotate 270", Wiring{Rotation: 270}, []int{3, 7, 11, 2, 6, 10, 1, 5, 9, 0, 4, 8}},
+		// two 3x2 panels side by side, each wired zig-zag
+		{"panels", Wiring{PanelColumns: 2, Serpentine: true}, []int{0, 1, 5, 4, 8, 9, 2, 3, 7, 6, 10, 11}},
+		// the second panel is mounted upside down
+		{"rotated panel", Wiring{PanelColumns: 2, PanelRotations: []int{0, 180}}, []int{0, 1, 4, 5, 8, 9, 11, 10, 7, 6, 3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.wiring.Rows, tt.wiring.Columns = 3, 4
+			l, err := tt.wiring.Layout()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strip(t, l, 4); !equal(got, tt.want) {
+				t.Errorf("strip = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTiledPanels checks a 16x64 display built from four 8x32 panels, chained
+// zig-zag down and across, with every other panel turned round
+func TestTiledPanels(t *testing.T) {
+	w := Wiring{
+		Rows: 16, Columns: 64,
+		PanelRows: 8, PanelColumns: 32,
+		ChainSerpentine: true,
+		PanelRotations:  []int{0, 180, 0, 180},
+		ColumnMajor:     true,
+		Serpentine:      true,
+	}
+	l, err := w.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := strip(t, l, 64)
+	seen := map[int]bool{}
+	for _, cell := range cells {
+		if seen[cell] {
+			t.Fatalf("cell %d shown by two LEDs", cell)
+		}
+		seen[cell] = true
+	}
+
+	checks := []struct {
+		index, row, column int
+	}{
+		{0, 0, 0},     // first panel starts top left, running down
+		{7, 7, 0},     //
+		{8, 7, 1},     // then back up the next column
+		{256, 7, 63},  // second panel is top right, turned round
+		{512, 8, 32},  // the chain zig-zags back along the second row of panels
+		{768, 15, 31}, // fourth panel is bottom left, turned round
+		{1023, 15, 0}, // its last column runs up, so turned round it ends bottom left
+	}
+	for _, c := range checks {
+		if row, column, _ := l.Cell(c.index); row != c.row || column != c.column {
+			t.Errorf("LED %d shows (%d, %d), want (%d, %d)", c.index, row, column, c.row, c.column)
+		}
+	}
+}
+
+func TestWiringRejects(t *testing.T) {
+	for _, w := range []Wiring{
+		{Rows: 0, Columns: 4},
+		{Rows: 4, Columns: 4, Rotation: 45},
+		{Rows: 4, Columns: 6, PanelColumns: 4},
+		{Rows: 4, Columns: 4, PanelColumns: 2, PanelRotations: []int{0, 0, 0}},
+		{Rows: 4, Columns: 4, PanelRotations: []int{30}},
+	} {
+		if _, err := w.Layout(); err == nil {
+			t.Errorf("%+v accepted", w)
+		}
+	}
+}
+
+func TestRowMajor(t *testing.T) {
+	l := RowMajor(2, 3)
+	if got := strip(t, l, 3); !equal(got, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("strip = %v", got)
+	}
+	if _, _, ok := l.Cell(6); ok {
+		t.Error("LED past the end shows a cell")
+	}
+}
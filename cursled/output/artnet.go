@@ -0,0 +1,125 @@
+package output
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// Art-Net constants from the Art-Net 4 specification
+const (
+	ArtNetPort      = 6454
+	artNetOpDmx     = 0x5000
+	artNetVersion   = 14
+	artDmxHeaderLen = 18
+	maxPortAddress  = 0x7FFF
+)
+
+var artNetID = [8]byte{'A', 'r', 't', '-', 'N', 'e', 't', 0}
+
+func init() {
+	transport.Register("artnet", openArtNet)
+}
+
+// ArtNet sends the display as ArtDmx packets, one per universe.  Art-Net has no
+// multicast; without a host the packets are broadcast.
+type ArtNet struct {
+	display   *display
+	universes UniverseMap
+	conn      *net.UDPConn
+	addr      *net.UDPAddr
+	sequence  map[uint16]uint8
+}
+
+// NewArtNet sends to addr, or broadcasts when addr is empty.  Universes are
+// 15 bit Art-Net port addresses, starting from 0.
+func NewArtNet(addr string, display transport.Display, universes UniverseMap) (*ArtNet, error) {
+	strip, err := newDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	if err := universes.validate(); err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		addr = fmt.Sprintf("255.255.255.255:%d", ArtNetPort)
+	}
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	// an unconnected socket can send to the broadcast address
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := enableBroadcast(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &ArtNet{display: strip, universes: universes, conn: conn, addr: raddr, sequence: make(map[uint16]uint8)}, nil
+}
+
+// openArtNet opens artnet://host[:port] for unicast or artnet:// to broadcast,
+// with optional universe and offset query parameters
+func openArtNet(u *url.URL, display transport.Display) (transport.Transport, error) {
+	universes, err := universeMapFromURL(u, 0)
+	if err != nil {
+		return nil, err
+	}
+	addr := ""
+	if u.Host != "" {
+		addr = hostPort(u, ArtNetPort)
+	}
+	return NewArtNet(addr, display, universes)
+}
+
+// Write applies one encoded frame and sends every universe of the display
+func (a *ArtNet) Write(p []byte) (int, error) {
+	if err := a.display.apply(p); err != nil {
+		return 0, err
+	}
+	for i, data := range a.universes.universes(a.display.pixels()) {
+		universe := int(a.universes.StartUniverse) + i
+		if universe > maxPortAddress {
+			return 0, fmt.Errorf("output: display runs past Art-Net universe %d", maxPortAddress)
+		}
+		if _, err := a.conn.WriteToUDP(a.packet(uint16(universe), data), a.addr); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// packet builds an ArtDmx packet carrying data for universe
+func (a *ArtNet) packet(universe uint16, data []byte) []byte {
+	if len(data)%2 == 1 {
+		data = append(data, 0) // the data length must be even
+	}
+	p := make([]byte, artDmxHeaderLen+len(data))
+	copy(p, artNetID[:])
+	binary.LittleEndian.PutUint16(p[8:], artNetOpDmx)
+	binary.BigEndian.PutUint16(p[10:], artNetVersion)
+	// sequence 0 turns reordering off at the receiver so it is skipped
+	if a.sequence[universe]++; a.sequence[universe] == 0 {
+		a.sequence[universe] = 1
+	}
+	p[12] = a.sequence[universe]
+	p[14] = byte(universe)      // sub-net and universe
+	p[15] = byte(universe >> 8) // net
+	binary.BigEndian.PutUint16(p[16:], uint16(len(data)))
+	copy(p[artDmxHeaderLen:], data)
+	return p
+}
+
+// Close closes the socket
+func (a *ArtNet) Close() error {
+	return a.conn.Close()
+}
+
+func (a *ArtNet) String() string {
+	return fmt.Sprintf("art-net %s from universe %d", a.addr, a.universes.StartUniverse)
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// parseArtDmx checks the fixed fields of an ArtDmx packet and returns its port address, sequence and DMX data
+func parseArtDmx(t *testing.T, p []byte) (universe uint16, sequence uint8, data []byte) {
+	t.Helper()
+	if len(p) < artDmxHeaderLen || !bytes.Equal(p[:8], artNetID[:]) || binary.LittleEndian.Uint16(p[8:]) != artNetOpDmx {
+		t.Fatalf("not an ArtDmx packet: % x", p)
+	}
+	if binary.BigEndian.Uint16(p[10:]) != artNetVersion {
+		t.Errorf("protocol version %d", binary.BigEndian.Uint16(p[10:]))
+	}
+	length := int(binary.BigEndian.Uint16(p[16:]))
+	if length%2 != 0 || length != len(p)-artDmxHeaderLen {
+		t.Errorf("data length %d for %d bytes of data", length, len(p)-artDmxHeaderLen)
+	}
+	return uint16(p[15])<<8 | uint16(p[14]), p[12], p[artDmxHeaderLen:]
+}
+
+func TestArtNet(t *testing.T) {
+	controller := listen(t)
+	sink, err := transport.Open("artnet://"+controller.LocalAddr().String()+"?universe=255", transport.Display{Header: testGrid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	m := UniverseMap{StartUniverse: 255}
+
+	f := testFrame()
+	for round := 1; round <= 2; round++ {
+		if _, err := sink.Write(encode(t, f)); err != nil {
+			t.Fatal(err)
+		}
+		universes := map[uint16][]byte{}
+		for _, p := range receive(t, controller, 3) {
+			universe, sequence, data := parseArtDmx(t, p)
+			if sequence != uint8(round) {
+				t.Errorf("universe %d sequence %d, want %d", universe, sequence, round)
+			}
+			universes[universe] = data
+		}
+		// 255 and 256 straddle the sub-net and net bytes of the port address
+		for _, universe := range []uint16{255, 256, 257} {
+			if _, ok := universes[universe]; !ok {
+				t.Errorf("universe %d not sent", universe)
+			}
+		}
+		sameDisplay(t, toFrame(universes, m, int(testGrid.Columns)), f)
+	}
+}
+
+func TestArtNetSequenceSkipsZero(t *testing.T) {
+	a := &ArtNet{sequence: map[uint16]uint8{0: 254}}
+	for _, want := range []uint8{255, 1, 2} {
+		if _, got, _ := parseArtDmx(t, a.packet(0, []byte{1, 2, 3})); got != want {
+			t.Errorf("sequence %d, want %d", got, want)
+		}
+	}
+}
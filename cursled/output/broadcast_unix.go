@@ -0,0 +1,24 @@
+//go:build unix
+
+package output
+
+import (
+	"net"
+	"syscall"
+)
+
+// enableBroadcast lets conn send to broadcast addresses
+func enableBroadcast(conn *net.UDPConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
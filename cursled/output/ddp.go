@@ -0,0 +1,123 @@
+package output
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// DDP constants from the Distributed Display Protocol specification
+const (
+	DDPPort          = 4048
+	DDPMaxData       = 1440 // bytes of pixel data per packet, 480 RGB pixels
+	ddpHeaderLen     = 10
+	ddpVersion1      = 0x40
+	ddpFlagPush      = 0x01
+	ddpTypeRGB24     = 0x0B // RGB, 8 bits per channel
+	ddpDefaultOutput = 1    // destination ID of a device's default output
+)
+
+func init() {
+	transport.Register("ddp", openDDP)
+}
+
+// DDP sends the display as DDP packets of at most DDPMaxData bytes, each at its
+// byte offset into the device's pixels.  The last packet of each frame carries
+// the push flag so the device shows the frame only once it is complete.
+type DDP struct {
+	display  *display
+	conn     *net.UDPConn
+	sequence uint8
+
+	// Offset the first pixel of the device to write to
+	Offset int
+	// Destination which of the device's outputs to write to
+	Destination uint8
+}
+
+// NewDDP sends to addr
+func NewDDP(addr string, display transport.Display) (*DDP, error) {
+	strip, err := newDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialUDP(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &DDP{display: strip, conn: conn, Destination: ddpDefaultOutput}, nil
+}
+
+// openDDP opens ddp://host[:port] with optional offset, in pixels, and destination query parameters
+func openDDP(u *url.URL, display transport.Display) (transport.Transport, error) {
+	d, err := NewDDP(hostPort(u, DDPPort), display)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	if offset := query.Get("offset"); offset != "" {
+		if d.Offset, err = strconv.Atoi(offset); err != nil || d.Offset < 0 {
+			d.Close()
+			return nil, fmt.Errorf("output: bad pixel offset %q", offset)
+		}
+	}
+	if destination := query.Get("destination"); destination != "" {
+		n, err := strconv.ParseUint(destination, 10, 8)
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("output: bad destination %q", destination)
+		}
+		d.Destination = uint8(n)
+	}
+	return d, nil
+}
+
+// Write applies one encoded frame and sends the whole display
+func (d *DDP) Write(p []byte) (int, error) {
+	if err := d.display.apply(p); err != nil {
+		return 0, err
+	}
+	pixels := d.display.pixels()
+	for sent := 0; sent < len(pixels); sent += DDPMaxData {
+		data := pixels[sent:]
+		last := len(data) <= DDPMaxData
+		if !last {
+			data = data[:DDPMaxData]
+		}
+		if _, err := d.conn.Write(d.packet(3*d.Offset+sent, data, last)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// packet builds a DDP packet writing data at byte offset, pushing the frame when push is set
+func (d *DDP) packet(offset int, data []byte, push bool) []byte {
+	p := make([]byte, ddpHeaderLen+len(data))
+	p[0] = ddpVersion1
+	if push {
+		p[0] |= ddpFlagPush
+	}
+	// sequence numbers run 1 to 15; 0 means the sender doesn't number packets
+	d.sequence = d.sequence%15 + 1
+	p[1] = d.sequence
+	p[2] = ddpTypeRGB24
+	p[3] = d.Destination
+	binary.BigEndian.PutUint32(p[4:], uint32(offset))
+	binary.BigEndian.PutUint16(p[8:], uint16(len(data)))
+	copy(p[ddpHeaderLen:], data)
+	return p
+}
+
+// Close closes the socket
+func (d *DDP) Close() error {
+	return d.conn.Close()
+}
+
+func (d *DDP) String() string {
+	return fmt.Sprintf("ddp %s from pixel %d", d.conn.RemoteAddr(), d.Offset)
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+func TestDDP(t *testing.T) {
+	controller := listen(t)
+	// 40x20 is 2400 bytes of pixels, two packets
+	grid := frame.ContainerHeader{Rows: 40, Columns: 20}
+	sink, err := transport.Open("ddp://"+controller.LocalAddr().String()+"?offset=10", transport.Display{Header: grid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	f := frame.Frame{}
+	for n := 0; n < 800; n += 7 {
+		f.LEDs = append(f.LEDs, frame.LEDInfo{Row: uint16(n / 20), Column: uint16(n % 20), Red: uint8(n), Green: 1, Blue: uint8(n >> 8), Brightness: 0xFF})
+	}
+	if _, err := sink.Write(encode(t, f)); err != nil {
+		t.Fatal(err)
+	}
+
+	device := make([]byte, 3*(10+800))
+	var lastSequence uint8
+	for i, p := range receive(t, controller, 2) {
+		if p[0]&0xC0 != ddpVersion1 || p[2] != ddpTypeRGB24 || p[3] != ddpDefaultOutput {
+			t.Errorf("packet %d header % x", i, p[:ddpHeaderLen])
+		}
+		if push, want := p[0]&ddpFlagPush != 0, i == 1; push != want {
+			t.Errorf("packet %d push %t, want %t", i, push, want)
+		}
+		if sequence := p[1]; sequence == 0 || sequence > 15 || sequence == lastSequence {
+			t.Errorf("packet %d sequence %d after %d", i, sequence, lastSequence)
+		} else {
+			lastSequence = sequence
+		}
+		offset, length := binary.BigEndian.Uint32(p[4:]), binary.BigEndian.Uint16(p[8:])
+		if int(length) != len(p)-ddpHeaderLen || length > DDPMaxData {
+			t.Errorf("packet %d length %d for %d bytes", i, length, len(p)-ddpHeaderLen)
+		}
+		if want := uint32(30 + i*DDPMaxData); offset != want {
+			t.Errorf("packet %d offset %d, want %d", i, offset, want)
+		}
+		copy(device[offset:], p[ddpHeaderLen:])
+	}
+
+	sameDisplay(t, pixelsToFrame(device[30:], 20), f)
+}
+
+func TestDDPSequenceWraps(t *testing.T) {
+	d := &DDP{sequence: 14}
+	for _, want := range []uint8{15, 1, 2} {
+		if got := d.packet(0, nil, false)[1]; got != want {
+			t.Errorf("sequence %d, want %d", got, want)
+		}
+	}
+}
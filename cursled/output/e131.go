@@ -0,0 +1,171 @@
+package output
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// E1.31 constants from ANSI E1.31-2016
+const (
+	E131Port            = 5568
+	E131DefaultPriority = 100
+	E131MaxUniverse     = 63999
+	e131HeaderSize      = 126 // root, framing and DMP layers up to and including the start code
+)
+
+var acnPacketIdentifier = [12]byte{'A', 'S', 'C', '-', 'E', '1', '.', '1', '7', 0, 0, 0}
+
+func init() {
+	transport.Register("e131", openE131)
+}
+
+// E131 sends the display as streaming ACN (sACN) data packets, one per universe
+type E131 struct {
+	display   *display
+	universes UniverseMap
+	conn      *net.UDPConn
+	multicast bool
+	cid       [16]byte
+	sequence  map[uint16]uint8
+
+	// Priority how receivers choose between sources sending the same universe, 0-200
+	Priority uint8
+	// SourceName shown by receivers to identify the sender
+	SourceName string
+}
+
+// NewE131 sends to addr, or to each universe's multicast group when addr is empty
+func NewE131(addr string, display transport.Display, universes UniverseMap) (*E131, error) {
+	strip, err := newDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	if err := universes.validate(); err != nil {
+		return nil, err
+	}
+	if universes.StartUniverse == 0 || universes.StartUniverse > E131MaxUniverse {
+		return nil, fmt.Errorf("output: E1.31 universe %d is not between 1 and %d", universes.StartUniverse, E131MaxUniverse)
+	}
+	e := &E131{
+		display:    strip,
+		universes:  universes,
+		multicast:  addr == "",
+		sequence:   make(map[uint16]uint8),
+		Priority:   E131DefaultPriority,
+		SourceName: "ledDraw",
+	}
+	rand.Read(e.cid[:])
+	if e.multicast {
+		e.conn, err = net.ListenUDP("udp", nil)
+	} else {
+		e.conn, err = dialUDP(addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// openE131 opens e131://host[:port] for unicast or e131:// for multicast, with
+// optional universe, offset and priority query parameters
+func openE131(u *url.URL, display transport.Display) (transport.Transport, error) {
+	universes, err := universeMapFromURL(u, 1)
+	if err != nil {
+		return nil, err
+	}
+	addr := ""
+	if u.Host != "" {
+		addr = hostPort(u, E131Port)
+	}
+	e, err := NewE131(addr, display, universes)
+	if err != nil {
+		return nil, err
+	}
+	if priority := u.Query().Get("priority"); priority != "" {
+		n, err := strconv.ParseUint(priority, 10, 8)
+		if err != nil || n > 200 {
+			e.Close()
+			return nil, fmt.Errorf("output: priority %q must be 0-200", priority)
+		}
+		e.Priority = uint8(n)
+	}
+	return e, nil
+}
+
+// MulticastGroup the address E1.31 receivers listen on for universe
+func MulticastGroup(universe uint16) net.IP {
+	return net.IPv4(239, 255, byte(universe>>8), byte(universe))
+}
+
+// Write applies one encoded frame and sends every universe of the display
+func (e *E131) Write(p []byte) (int, error) {
+	if err := e.display.apply(p); err != nil {
+		return 0, err
+	}
+	for i, data := range e.universes.universes(e.display.pixels()) {
+		if int(e.universes.StartUniverse)+i > E131MaxUniverse {
+			return 0, fmt.Errorf("output: display runs past E1.31 universe %d", E131MaxUniverse)
+		}
+		universe := e.universes.StartUniverse + uint16(i)
+		packet := e.packet(universe, data)
+		var err error
+		if e.multicast {
+			_, err = e.conn.WriteToUDP(packet, &net.UDPAddr{IP: MulticastGroup(universe), Port: E131Port})
+		} else {
+			_, err = e.conn.Write(packet)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// packet builds an E1.31 data packet carrying data for universe
+func (e *E131) packet(universe uint16, data []byte) []byte {
+	p := make([]byte, e131HeaderSize+len(data))
+	length := func(from int) uint16 { return 0x7000 | uint16(len(p)-from) }
+
+	// root layer
+	binary.BigEndian.PutUint16(p[0:], 0x0010) // preamble size
+	copy(p[4:], acnPacketIdentifier[:])
+	binary.BigEndian.PutUint16(p[16:], length(16))
+	binary.BigEndian.PutUint32(p[18:], 0x00000004) // VECTOR_ROOT_E131_DATA
+	copy(p[22:], e.cid[:])
+
+	// framing layer
+	binary.BigEndian.PutUint16(p[38:], length(38))
+	binary.BigEndian.PutUint32(p[40:], 0x00000002) // VECTOR_E131_DATA_PACKET
+	copy(p[44:108], e.SourceName)
+	p[108] = e.Priority
+	p[111] = e.sequence[universe]
+	e.sequence[universe]++
+	binary.BigEndian.PutUint16(p[113:], universe)
+
+	// DMP layer
+	binary.BigEndian.PutUint16(p[115:], length(115))
+	p[117] = 0x02                                            // VECTOR_DMP_SET_PROPERTY
+	p[118] = 0xA1                                            // address and data type
+	binary.BigEndian.PutUint16(p[121:], 1)                   // address increment
+	binary.BigEndian.PutUint16(p[123:], uint16(len(data)+1)) // start code and slots
+	copy(p[e131HeaderSize:], data)                           // after the zero start code
+	return p
+}
+
+// Close closes the socket
+func (e *E131) Close() error {
+	return e.conn.Close()
+}
+
+func (e *E131) String() string {
+	if e.multicast {
+		return fmt.Sprintf("e1.31 multicast from universe %d", e.universes.StartUniverse)
+	}
+	return fmt.Sprintf("e1.31 %s from universe %d", e.conn.RemoteAddr(), e.universes.StartUniverse)
+}
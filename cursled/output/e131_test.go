@@ -0,0 +1,86 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// parseE131 checks the fixed fields of an E1.31 data packet and returns its universe, sequence, priority and DMX data
+func parseE131(t *testing.T, p []byte) (universe uint16, sequence, priority uint8, data []byte) {
+	t.Helper()
+	if len(p) < e131HeaderSize || !bytes.Equal(p[4:16], acnPacketIdentifier[:]) {
+		t.Fatalf("not an E1.31 packet: % x", p)
+	}
+	for _, layer := range []int{16, 38, 115} {
+		if got := binary.BigEndian.Uint16(p[layer:]); got != 0x7000|uint16(len(p)-layer) {
+			t.Errorf("layer at %d has flags and length %04x for a %d byte packet", layer, got, len(p))
+		}
+	}
+	if binary.BigEndian.Uint32(p[18:]) != 4 || binary.BigEndian.Uint32(p[40:]) != 2 || p[117] != 2 || p[118] != 0xA1 {
+		t.Errorf("bad vectors in % x", p[:e131HeaderSize])
+	}
+	if count := int(binary.BigEndian.Uint16(p[123:])); count != len(p)-e131HeaderSize+1 || p[125] != 0 {
+		t.Errorf("property count %d and start code %d for %d slots", count, p[125], len(p)-e131HeaderSize)
+	}
+	return binary.BigEndian.Uint16(p[113:]), p[111], p[108], p[e131HeaderSize:]
+}
+
+func TestE131(t *testing.T) {
+	controller := listen(t)
+	sink, err := transport.Open("e131://"+controller.LocalAddr().String()+"?universe=5&offset=3&priority=150", transport.Display{Header: testGrid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	m := UniverseMap{StartUniverse: 5, ChannelOffset: 3}
+
+	f := testFrame()
+	for round := 0; round < 2; round++ {
+		if _, err := sink.Write(encode(t, f)); err != nil {
+			t.Fatal(err)
+		}
+		universes := map[uint16][]byte{}
+		for _, p := range receive(t, controller, 3) {
+			universe, sequence, priority, data := parseE131(t, p)
+			if sequence != uint8(round) {
+				t.Errorf("universe %d sequence %d, want %d", universe, sequence, round)
+			}
+			if priority != 150 {
+				t.Errorf("priority %d, want 150", priority)
+			}
+			universes[universe] = data
+		}
+		for _, universe := range []uint16{5, 6, 7} {
+			if _, ok := universes[universe]; !ok {
+				t.Errorf("universe %d not sent", universe)
+			}
+		}
+		sameDisplay(t, toFrame(universes, m, int(testGrid.Columns)), f)
+	}
+}
+
+func TestE131Rejects(t *testing.T) {
+	for _, url := range []string{
+		"e131://127.0.0.1?universe=0",
+		"e131://127.0.0.1?universe=64000",
+		"e131://127.0.0.1?priority=201",
+		"e131://127.0.0.1?offset=511",
+	} {
+		if sink, err := transport.Open(url, transport.Display{Header: testGrid}); err == nil {
+			sink.Close()
+			t.Errorf("Open(%q) accepted", url)
+		}
+	}
+	if _, err := transport.Open("e131://127.0.0.1", transport.Display{}); err == nil {
+		t.Error("Open without a grid size accepted")
+	}
+}
+
+func TestMulticastGroup(t *testing.T) {
+	if got := MulticastGroup(0x0102).String(); got != "239.255.1.2" {
+		t.Errorf("MulticastGroup(258) = %s", got)
+	}
+}
@@ -0,0 +1,144 @@
+// Package output drives LED controllers that speak lighting protocols rather
+// than the frame stream.  Each driver is a transport.Transport: it decodes the
+// frames written to it and sends the whole display in its own protocol, with
+// the pixels in the order the display's layout says they are wired.
+// Importing the package registers its URL schemes with transport.Open.
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/layout"
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// display rebuilds the grid from the encoded frames a driver is given and lays
+// it out as one strip of pixels in the order they are wired
+type display struct {
+	layout layout.Layout
+	state  *frame.State
+}
+
+func newDisplay(d transport.Display) (*display, error) {
+	l := d.Layout
+	if l == nil {
+		if d.Header.Rows == 0 || d.Header.Columns == 0 {
+			return nil, fmt.Errorf("output: grid size is needed to lay out pixels")
+		}
+		l = layout.RowMajor(int(d.Header.Rows), int(d.Header.Columns))
+	}
+	return &display{layout: l, state: frame.NewState()}, nil
+}
+
+// apply decodes the frame in p onto the display
+func (d *display) apply(p []byte) error {
+	f, err := frame.NewDecoder(bytes.NewReader(p)).Decode()
+	if err != nil {
+		return err
+	}
+	d.state.Apply(f)
+	return nil
+}
+
+// pixels the color of every pixel on the strip, 3 bytes of red, green and blue
+// each, with brightness applied
+func (d *display) pixels() []byte {
+	pixels := make([]byte, 3*d.layout.Len())
+	for i := 0; i < d.layout.Len(); i++ {
+		row, column, ok := d.layout.Cell(i)
+		if !ok {
+			continue
+		}
+		led, ok := d.state.Get(uint16(row), uint16(column))
+		if !ok {
+			continue
+		}
+		c := led.RGBA()
+		pixels[3*i], pixels[3*i+1], pixels[3*i+2] = c.R, c.G, c.B
+	}
+	return pixels
+}
+
+// DMX universes carry 512 channels; 170 RGB pixels fill all but two
+const (
+	UniverseSize         = 512
+	MaxPixelsPerUniverse = UniverseSize / 3
+)
+
+// UniverseMap spreads a strip of RGB pixels over consecutive DMX universes
+type UniverseMap struct {
+	StartUniverse uint16 // universe of the first pixel
+	ChannelOffset int    // channels left unused at the start of each universe, 0-based
+}
+
+// PixelsPerUniverse how many whole RGB pixels fit in each universe after the offset
+func (m UniverseMap) PixelsPerUniverse() int {
+	return (UniverseSize - m.ChannelOffset) / 3
+}
+
+func (m UniverseMap) validate() error {
+	if m.ChannelOffset < 0 || m.PixelsPerUniverse() < 1 {
+		return fmt.Errorf("output: channel offset %d leaves no room for a pixel", m.ChannelOffset)
+	}
+	return nil
+}
+
+// universes splits pixels into the DMX data of each universe, in order from StartUniverse
+func (m UniverseMap) universes(pixels []byte) [][]byte {
+	perUniverse := 3 * m.PixelsPerUniverse()
+	var universes [][]byte
+	for len(pixels) > 0 {
+		n := perUniverse
+		if n > len(pixels) {
+			n = len(pixels)
+		}
+		data := make([]byte, m.ChannelOffset+n)
+		copy(data[m.ChannelOffset:], pixels[:n])
+		universes = append(universes, data)
+		pixels = pixels[n:]
+	}
+	return universes
+}
+
+// universeMapFromURL reads the universe and offset query parameters
+func universeMapFromURL(u *url.URL, defaultUniverse uint16) (UniverseMap, error) {
+	m := UniverseMap{StartUniverse: defaultUniverse}
+	query := u.Query()
+	if universe := query.Get("universe"); universe != "" {
+		n, err := strconv.ParseUint(universe, 10, 16)
+		if err != nil {
+			return m, fmt.Errorf("output: bad universe %q", universe)
+		}
+		m.StartUniverse = uint16(n)
+	}
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil {
+			return m, fmt.Errorf("output: bad channel offset %q", offset)
+		}
+		m.ChannelOffset = n
+	}
+	return m, m.validate()
+}
+
+// hostPort adds port to the host of u when it has none
+func hostPort(u *url.URL, port int) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+}
+
+// dialUDP opens a socket sending to addr
+func dialUDP(addr string) (*net.UDPConn, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return net.DialUDP("udp", nil, raddr)
+}
@@ -0,0 +1,168 @@
+package output
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/layout"
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// testGrid is 20x20, so 400 pixels spread over three universes of 170
+var testGrid = frame.ContainerHeader{Rows: 20, Columns: 20}
+
+func testFrame() frame.Frame {
+	f := frame.Frame{}
+	for n := 0; n < int(testGrid.Rows)*int(testGrid.Columns); n += 3 {
+		f.LEDs = append(f.LEDs, frame.LEDInfo{
+			Row: uint16(n / 20), Column: uint16(n % 20),
+			Red: uint8(n), Green: uint8(n >> 8), Blue: 0x80, Brightness: 0xFF,
+		})
+	}
+	return f
+}
+
+// listen returns a local UDP socket standing in for a controller
+func listen(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+// receive reads n packets from conn
+func receive(t *testing.T, conn *net.UDPConn, n int) [][]byte {
+	var packets [][]byte
+	buf := make([]byte, 1500)
+	for i := 0; i < n; i++ {
+		size, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packets = append(packets, append([]byte(nil), buf[:size]...))
+	}
+	return packets
+}
+
+// toFrame turns the DMX data received for each universe back into a frame of lit LEDs
+func toFrame(universes map[uint16][]byte, m UniverseMap, columns int) frame.Frame {
+	perUniverse := m.PixelsPerUniverse()
+	var strip []byte
+	for universe, data := range universes {
+		start := 3 * int(universe-m.StartUniverse) * perUniverse
+		if need := start + len(data) - m.ChannelOffset; need > len(strip) {
+			strip = append(strip, make([]byte, need-len(strip))...)
+		}
+		copy(strip[start:], data[m.ChannelOffset:])
+	}
+	return pixelsToFrame(strip, columns)
+}
+
+// pixelsToFrame turns a strip of RGB pixels, row by row, into a frame of lit LEDs
+func pixelsToFrame(pixels []byte, columns int) frame.Frame {
+	f := frame.Frame{}
+	for i := 0; i+3 <= len(pixels); i += 3 {
+		if pixels[i] == 0 && pixels[i+1] == 0 && pixels[i+2] == 0 {
+			continue
+		}
+		n := i / 3
+		f.LEDs = append(f.LEDs, frame.LEDInfo{
+			Row: uint16(n / columns), Column: uint16(n % columns),
+			Red: pixels[i], Green: pixels[i+1], Blue: pixels[i+2], Brightness: 0xFF,
+		})
+	}
+	return f
+}
+
+// sameDisplay compares two frames as whole displays
+func sameDisplay(t *testing.T, got, want frame.Frame) {
+	t.Helper()
+	gotState, wantState := frame.NewState(), frame.NewState()
+	gotState.Apply(got)
+	wantState.Apply(want)
+	g, w := gotState.LEDs(), wantState.LEDs()
+	if len(g) != len(w) {
+		t.Fatalf("got %d lit LEDs, want %d", len(g), len(w))
+	}
+	for i := range g {
+		if g[i] != w[i] {
+			t.Errorf("LED %d = %+v, want %+v", i, g[i], w[i])
+		}
+	}
+}
+
+func encode(t *testing.T, f frame.Frame) []byte {
+	var buf bytes.Buffer
+	if err := frame.NewEncoder(&buf).Encode(f); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDisplayWiring(t *testing.T) {
+	// 2x3 wired zig-zag: the second row runs right to left
+	wiring, err := layout.Wiring{Rows: 2, Columns: 3, Serpentine: true}.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := newDisplay(transport.Display{Layout: wiring})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := frame.Frame{}
+	for n := 0; n < 6; n++ {
+		f.LEDs = append(f.LEDs, frame.LEDInfo{Row: uint16(n / 3), Column: uint16(n % 3), Red: uint8(n + 1), Brightness: 0xFF})
+	}
+	if err := d.apply(encode(t, f)); err != nil {
+		t.Fatal(err)
+	}
+	var reds []byte
+	for i, pixels := 0, d.pixels(); i < len(pixels); i += 3 {
+		reds = append(reds, pixels[i])
+	}
+	if want := []byte{1, 2, 3, 6, 5, 4}; !bytes.Equal(reds, want) {
+		t.Errorf("strip reds %v, want %v", reds, want)
+	}
+
+	if _, err := newDisplay(transport.Display{}); err == nil {
+		t.Error("display without a grid size or layout was accepted")
+	}
+}
+
+func TestUniverseMap(t *testing.T) {
+	tests := []struct {
+		offset  int
+		perUni  int
+		pixels  int
+		lengths []int
+	}{
+		{0, 170, 400, []int{510, 510, 180}},
+		{0, 170, 170, []int{510}},
+		{10, 167, 170, []int{511, 19}},
+	}
+	for _, tt := range tests {
+		m := UniverseMap{StartUniverse: 1, ChannelOffset: tt.offset}
+		if got := m.PixelsPerUniverse(); got != tt.perUni {
+			t.Errorf("offset %d: PixelsPerUniverse() = %d, want %d", tt.offset, got, tt.perUni)
+		}
+		universes := m.universes(make([]byte, 3*tt.pixels))
+		if len(universes) != len(tt.lengths) {
+			t.Fatalf("offset %d: %d universes, want %d", tt.offset, len(universes), len(tt.lengths))
+		}
+		for i, data := range universes {
+			if len(data) != tt.lengths[i] {
+				t.Errorf("offset %d: universe %d has %d channels, want %d", tt.offset, i, len(data), tt.lengths[i])
+			}
+		}
+	}
+
+	if err := (UniverseMap{ChannelOffset: 510}).validate(); err == nil {
+		t.Error("offset leaving no room for a pixel was accepted")
+	}
+}
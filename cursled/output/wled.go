@@ -0,0 +1,135 @@
+package output
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+// WLED realtime UDP protocol constants, from the WLED documentation
+const (
+	WLEDPort           = 21324
+	WLEDDefaultTimeout = 2   // seconds
+	WLEDNoTimeout      = 255 // stay in realtime mode until told otherwise
+	wledDRGBMaxPixels  = 490
+	wledDNRGBMaxPixels = 489
+)
+
+// WLEDMode which realtime protocol to send
+type WLEDMode uint8
+
+// WLED realtime modes
+const (
+	WLEDDRGB  WLEDMode = 2 // every pixel from the first, up to 490
+	WLEDDNRGB WLEDMode = 4 // runs of up to 489 pixels from a start index
+)
+
+func init() {
+	transport.Register("wled", openWLED)
+}
+
+// WLED sends the display with WLED's UDP realtime protocols.  Each packet holds
+// the timeout after which WLED goes back to its own effects if packets stop.
+type WLED struct {
+	display *display
+	conn    *net.UDPConn
+
+	// Mode DRGB for displays of up to 490 pixels, otherwise DNRGB
+	Mode WLEDMode
+	// Timeout seconds WLED waits for the next packet; WLEDNoTimeout to wait forever
+	Timeout uint8
+}
+
+// NewWLED sends to addr, choosing DRGB when the whole display fits in one packet
+func NewWLED(addr string, display transport.Display) (*WLED, error) {
+	strip, err := newDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialUDP(addr)
+	if err != nil {
+		return nil, err
+	}
+	w := &WLED{display: strip, conn: conn, Mode: WLEDDNRGB, Timeout: WLEDDefaultTimeout}
+	if strip.layout.Len() <= wledDRGBMaxPixels {
+		w.Mode = WLEDDRGB
+	}
+	return w, nil
+}
+
+// openWLED opens wled://host[:port] with optional mode (drgb or dnrgb) and timeout query parameters
+func openWLED(u *url.URL, display transport.Display) (transport.Transport, error) {
+	w, err := NewWLED(hostPort(u, WLEDPort), display)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	switch query.Get("mode") {
+	case "":
+	case "drgb":
+		w.Mode = WLEDDRGB
+	case "dnrgb":
+		w.Mode = WLEDDNRGB
+	default:
+		w.Close()
+		return nil, fmt.Errorf("output: unknown WLED mode %q", query.Get("mode"))
+	}
+	if w.Mode == WLEDDRGB && w.display.layout.Len() > wledDRGBMaxPixels {
+		w.Close()
+		return nil, fmt.Errorf("output: DRGB can't send more than %d pixels", wledDRGBMaxPixels)
+	}
+	if timeout := query.Get("timeout"); timeout != "" {
+		n, err := strconv.ParseUint(timeout, 10, 8)
+		if err != nil || n == 0 {
+			w.Close()
+			return nil, fmt.Errorf("output: timeout %q must be 1-255 seconds", timeout)
+		}
+		w.Timeout = uint8(n)
+	}
+	return w, nil
+}
+
+// Write applies one encoded frame and sends the whole display
+func (w *WLED) Write(p []byte) (int, error) {
+	if err := w.display.apply(p); err != nil {
+		return 0, err
+	}
+	pixels := w.display.pixels()
+	if w.Mode == WLEDDRGB {
+		if len(pixels) > 3*wledDRGBMaxPixels {
+			return 0, fmt.Errorf("output: DRGB can't send more than %d pixels", wledDRGBMaxPixels)
+		}
+		packet := append([]byte{byte(WLEDDRGB), w.Timeout}, pixels...)
+		if _, err := w.conn.Write(packet); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	for start := 0; start < len(pixels)/3; start += wledDNRGBMaxPixels {
+		run := pixels[3*start:]
+		if len(run) > 3*wledDNRGBMaxPixels {
+			run = run[:3*wledDNRGBMaxPixels]
+		}
+		packet := make([]byte, 4, 4+len(run))
+		packet[0], packet[1] = byte(WLEDDNRGB), w.Timeout
+		binary.BigEndian.PutUint16(packet[2:], uint16(start))
+		if _, err := w.conn.Write(append(packet, run...)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close closes the socket
+func (w *WLED) Close() error {
+	return w.conn.Close()
+}
+
+func (w *WLED) String() string {
+	return fmt.Sprintf("wled %s mode %d", w.conn.RemoteAddr(), w.Mode)
+}
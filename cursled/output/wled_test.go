@@ -0,0 +1,84 @@
+package output
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/transport"
+)
+
+func TestWLEDDRGB(t *testing.T) {
+	controller := listen(t)
+	grid := frame.ContainerHeader{Rows: 8, Columns: 8}
+	sink, err := transport.Open("wled://"+controller.LocalAddr().String()+"?timeout=5", transport.Display{Header: grid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	f := frame.Frame{LEDs: []frame.LEDInfo{
+		{Row: 0, Column: 3, Red: 0xFF, Brightness: 0xFF},
+		{Row: 7, Column: 7, Green: 0x10, Blue: 0x20, Brightness: 0xFF},
+	}}
+	if _, err := sink.Write(encode(t, f)); err != nil {
+		t.Fatal(err)
+	}
+	p := receive(t, controller, 1)[0]
+	if p[0] != byte(WLEDDRGB) || p[1] != 5 {
+		t.Errorf("header % x, want DRGB with a 5 second timeout", p[:2])
+	}
+	if len(p) != 2+3*64 {
+		t.Errorf("packet of %d bytes, want %d", len(p), 2+3*64)
+	}
+	sameDisplay(t, pixelsToFrame(p[2:], 8), f)
+}
+
+func TestWLEDDNRGB(t *testing.T) {
+	controller := listen(t)
+	// 40x20 is 800 pixels, too many for DRGB
+	grid := frame.ContainerHeader{Rows: 40, Columns: 20}
+	sink, err := transport.Open("wled://"+controller.LocalAddr().String(), transport.Display{Header: grid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	f := frame.Frame{}
+	for n := 0; n < 800; n += 11 {
+		f.LEDs = append(f.LEDs, frame.LEDInfo{Row: uint16(n / 20), Column: uint16(n % 20), Red: uint8(n), Green: 0x40, Brightness: 0xFF})
+	}
+	if _, err := sink.Write(encode(t, f)); err != nil {
+		t.Fatal(err)
+	}
+
+	strip := make([]byte, 3*800)
+	for i, p := range receive(t, controller, 2) {
+		if p[0] != byte(WLEDDNRGB) || p[1] != WLEDDefaultTimeout {
+			t.Errorf("packet %d header % x", i, p[:2])
+		}
+		start := int(binary.BigEndian.Uint16(p[2:]))
+		if want := i * wledDNRGBMaxPixels; start != want {
+			t.Errorf("packet %d starts at %d, want %d", i, start, want)
+		}
+		if pixels := (len(p) - 4) / 3; pixels > wledDNRGBMaxPixels {
+			t.Errorf("packet %d has %d pixels", i, pixels)
+		}
+		copy(strip[3*start:], p[4:])
+	}
+	sameDisplay(t, pixelsToFrame(strip, 20), f)
+}
+
+func TestWLEDRejects(t *testing.T) {
+	big := frame.ContainerHeader{Rows: 40, Columns: 20}
+	for _, url := range []string{
+		"wled://127.0.0.1?mode=drgb",
+		"wled://127.0.0.1?mode=warls",
+		"wled://127.0.0.1?timeout=0",
+	} {
+		if sink, err := transport.Open(url, transport.Display{Header: big}); err == nil {
+			sink.Close()
+			t.Errorf("Open(%q) accepted", url)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package picture loads images and animations and resamples them to the size
+// of the LED grid, one pixel per LED
+package picture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg" // registers JPEG with image.Decode
+	_ "image/png"  // registers PNG with image.Decode
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// Fit how an image is scaled to a grid of a different shape
+type Fit int
+
+const (
+	// FitContain scales the whole image to fit inside the grid, leaving the rest transparent
+	FitContain Fit = iota
+	// FitCover scales the image to cover the whole grid, cropping what hangs over
+	FitCover
+	// FitStretch scales each way on its own to exactly fill the grid
+	FitStretch
+)
+
+// Filter how the pixels of an image are combined into each cell
+type Filter int
+
+const (
+	// FilterNearest takes the pixel at the middle of each cell, keeping pixel art crisp
+	FilterNearest Filter = iota
+	// FilterArea averages every pixel a cell covers, weighted by how much of it is covered
+	FilterArea
+)
+
+// ParseFit reads fit, fill or stretch
+func ParseFit(s string) (Fit, error) {
+	switch s {
+	case "fit":
+		return FitContain, nil
+	case "fill":
+		return FitCover, nil
+	case "stretch":
+		return FitStretch, nil
+	}
+	return 0, fmt.Errorf("picture: fit %q is not fit, fill or stretch", s)
+}
+
+// ParseFilter reads nearest or area
+func ParseFilter(s string) (Filter, error) {
+	switch s {
+	case "nearest":
+		return FilterNearest, nil
+	case "area":
+		return FilterArea, nil
+	}
+	return 0, fmt.Errorf("picture: filter %q is not nearest or area", s)
+}
+
+// Options the grid an image is resampled to and how
+type Options struct {
+	Rows, Columns int
+	Fit           Fit
+	Filter        Filter
+}
+
+// Frame one image of an animation and how long it is shown; a still image is
+// a single Frame with no delay
+type Frame struct {
+	Image *image.NRGBA
+	Delay time.Duration
+}
+
+// Open reads a PNG, JPEG or GIF and resamples every frame of it
+func Open(path string, o Options) ([]Frame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	frames, err := Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("picture: %s: %w", path, err)
+	}
+	for i := range frames {
+		frames[i].Image = Resample(frames[i].Image, o)
+	}
+	return frames, nil
+}
+
+// Decode reads an image at its own size.  Each frame of an animated GIF is
+// drawn over the ones before it as the GIF says to, so every Frame is whole.
+func Decode(r io.Reader) ([]Frame, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(6); bytes.HasPrefix(magic, []byte("GIF8")) {
+		g, err := gif.DecodeAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return gifFrames(g), nil
+	}
+	img, _, err := image.Decode(br)
+	if err != nil {
+		return nil, err
+	}
+	return []Frame{{Image: toNRGBA(img)}}, nil
+}
+
+// gifFrames composes the frames of g, which may each cover only part of it
+func gifFrames(g *gif.GIF) []Frame {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+	composed := image.NewNRGBA(bounds)
+	frames := make([]Frame, len(g.Image))
+	for i, img := range g.Image {
+		var previous *image.NRGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = copyNRGBA(composed)
+		}
+		draw.Draw(composed, img.Bounds(), img, img.Bounds().Min, draw.Over)
+		frames[i] = Frame{Image: copyNRGBA(composed)}
+		if i < len(g.Delay) {
+			frames[i].Delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(composed, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			composed = previous
+		}
+	}
+	return frames
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n
+	}
+	n := image.NewNRGBA(img.Bounds())
+	draw.Draw(n, n.Bounds(), img, img.Bounds().Min, draw.Src)
+	return n
+}
+
+func copyNRGBA(img *image.NRGBA) *image.NRGBA {
+	n := image.NewNRGBA(img.Bounds())
+	copy(n.Pix, img.Pix)
+	return n
+}
+
+// Resample scales img to o.Columns x o.Rows pixels
+func Resample(img image.Image, o Options) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, o.Columns, o.Rows))
+	src := img.Bounds()
+	if src.Empty() || o.Rows < 1 || o.Columns < 1 {
+		return dst
+	}
+
+	// cell x covers source pixels (x-offsetX)/scaleX to (x+1-offsetX)/scaleX
+	scaleX := float64(o.Columns) / float64(src.Dx())
+	scaleY := float64(o.Rows) / float64(src.Dy())
+	switch o.Fit {
+	case FitContain:
+		scaleX = math.Min(scaleX, scaleY)
+		scaleY = scaleX
+	case FitCover:
+		scaleX = math.Max(scaleX, scaleY)
+		scaleY = scaleX
+	}
+	offsetX := (float64(o.Columns) - float64(src.Dx())*scaleX) / 2
+	offsetY := (float64(o.Rows) - float64(src.Dy())*scaleY) / 2
+
+	for y := 0; y < o.Rows; y++ {
+		for x := 0; x < o.Columns; x++ {
+			x0, x1 := (float64(x)-offsetX)/scaleX, (float64(x+1)-offsetX)/scaleX
+			y0, y1 := (float64(y)-offsetY)/scaleY, (float64(y+1)-offsetY)/scaleY
+			var c color.NRGBA
+			if o.Filter == FilterNearest {
+				c = nearest(img, (x0+x1)/2, (y0+y1)/2)
+			} else {
+				c = areaAverage(img, x0, y0, x1, y1)
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
+
+// nearest the pixel at x, y from the top left of img; transparent outside it
+func nearest(img image.Image, x, y float64) color.NRGBA {
+	b := img.Bounds()
+	px, py := int(math.Floor(x)), int(math.Floor(y))
+	if px < 0 || py < 0 || px >= b.Dx() || py >= b.Dy() {
+		return color.NRGBA{}
+	}
+	return color.NRGBAModel.Convert(img.At(b.Min.X+px, b.Min.Y+py)).(color.NRGBA)
+}
+
+// areaAverage the average of the pixels from x0, y0 to x1, y1 of img, each
+// weighted by how much of it is in the area.  Parts of the area outside img
+// count as transparent.
+func areaAverage(img image.Image, x0, y0, x1, y1 float64) color.NRGBA {
+	b := img.Bounds()
+	area := (x1 - x0) * (y1 - y0)
+	if area <= 0 {
+		return color.NRGBA{}
+	}
+	var r, g, bl, a float64 // alpha premultiplied
+	for py := int(math.Max(math.Floor(y0), 0)); py < b.Dy() && float64(py) < y1; py++ {
+		h := math.Min(float64(py+1), y1) - math.Max(float64(py), y0)
+		for px := int(math.Max(math.Floor(x0), 0)); px < b.Dx() && float64(px) < x1; px++ {
+			w := math.Min(float64(px+1), x1) - math.Max(float64(px), x0)
+			pr, pg, pb, pa := img.At(b.Min.X+px, b.Min.Y+py).RGBA()
+			weight := w * h
+			r += float64(pr) * weight
+			g += float64(pg) * weight
+			bl += float64(pb) * weight
+			a += float64(pa) * weight
+		}
+	}
+	if a == 0 {
+		return color.NRGBA{}
+	}
+	// dividing by alpha takes the premultiplying back out
+	return color.NRGBA{R: to8(r / a), G: to8(g / a), B: to8(bl / a), A: to8(a / area / 0xFFFF)}
+}
+
+// to8 turns a channel from 0 to 1 into 8 bits, rounding and clamping
+func to8(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v*255))))
+}
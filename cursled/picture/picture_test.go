@@ -0,0 +1,148 @@
+package picture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+)
+
+var (
+	red   = color.NRGBA{R: 255, A: 255}
+	green = color.NRGBA{G: 255, A: 255}
+	blue  = color.NRGBA{B: 255, A: 255}
+	none  = color.NRGBA{}
+)
+
+// pixels makes an image from rows of colors
+func pixels(rows ...[]color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func sameImage(t *testing.T, name string, got *image.NRGBA, want *image.NRGBA) {
+	t.Helper()
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("%s: %v image, want %v", name, got.Bounds(), want.Bounds())
+	}
+	for y := 0; y < want.Bounds().Dy(); y++ {
+		for x := 0; x < want.Bounds().Dx(); x++ {
+			if g, w := got.NRGBAAt(x, y), want.NRGBAAt(x, y); g != w {
+				t.Errorf("%s: pixel %d,%d = %v, want %v", name, x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	halves := pixels(
+		[]color.NRGBA{red, red, blue, blue},
+		[]color.NRGBA{red, red, blue, blue},
+	)
+	purple := color.NRGBA{R: 128, B: 128, A: 255}
+	tests := []struct {
+		name string
+		img  image.Image
+		o    Options
+		want *image.NRGBA
+	}{
+		{"contain leaves the sides transparent", pixels([]color.NRGBA{red}), Options{Rows: 1, Columns: 3, Fit: FitContain, Filter: FilterArea},
+			pixels([]color.NRGBA{none, red, none})},
+		{"contain nearest", pixels([]color.NRGBA{red}), Options{Rows: 1, Columns: 3, Fit: FitContain, Filter: FilterNearest},
+			pixels([]color.NRGBA{none, red, none})},
+		{"cover crops the sides", halves, Options{Rows: 1, Columns: 1, Fit: FitCover, Filter: FilterArea},
+			pixels([]color.NRGBA{purple})},
+		{"cover nearest", halves, Options{Rows: 1, Columns: 1, Fit: FitCover, Filter: FilterNearest},
+			pixels([]color.NRGBA{blue})},
+		{"stretch", halves, Options{Rows: 1, Columns: 2, Fit: FitStretch, Filter: FilterArea},
+			pixels([]color.NRGBA{red, blue})},
+		{"area averages alpha and color", pixels([]color.NRGBA{red, green}, []color.NRGBA{blue, none}), Options{Rows: 1, Columns: 1, Fit: FitStretch, Filter: FilterArea},
+			pixels([]color.NRGBA{{R: 85, G: 85, B: 85, A: 191}})},
+		{"nearest keeps pixel art crisp", pixels([]color.NRGBA{red, blue}), Options{Rows: 2, Columns: 4, Fit: FitStretch, Filter: FilterNearest},
+			pixels([]color.NRGBA{red, red, blue, blue}, []color.NRGBA{red, red, blue, blue})},
+	}
+	for _, tt := range tests {
+		sameImage(t, tt.name, Resample(tt.img, tt.o), tt.want)
+	}
+}
+
+func TestDecodeStill(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, pixels([]color.NRGBA{red, blue}))
+	frames, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || frames[0].Delay != 0 {
+		t.Fatalf("%d frames, first shown for %v", len(frames), frames[0].Delay)
+	}
+	sameImage(t, "png", frames[0].Image, pixels([]color.NRGBA{red, blue}))
+
+	if _, err := Decode(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Error("decoded something that isn't an image")
+	}
+}
+
+func TestDecodeAnimatedGIF(t *testing.T) {
+	palette := color.Palette{color.Transparent, red, green, blue}
+	frame := func(r image.Rectangle, index uint8) *image.Paletted {
+		img := image.NewPaletted(r, palette)
+		for i := range img.Pix {
+			img.Pix[i] = index
+		}
+		return img
+	}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			frame(image.Rect(0, 0, 2, 2), 1), // all red
+			frame(image.Rect(1, 1, 2, 2), 3), // blue corner, cleared afterwards
+			frame(image.Rect(0, 0, 1, 1), 2), // green corner
+		},
+		Delay:    []int{10, 25, 5},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalNone},
+		Config:   image.Config{Width: 2, Height: 2, ColorModel: palette},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	frames, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("%d frames, want 3", len(frames))
+	}
+	for i, want := range []time.Duration{100 * time.Millisecond, 250 * time.Millisecond, 50 * time.Millisecond} {
+		if frames[i].Delay != want {
+			t.Errorf("frame %d delay %v, want %v", i, frames[i].Delay, want)
+		}
+	}
+	sameImage(t, "frame 0", frames[0].Image, pixels([]color.NRGBA{red, red}, []color.NRGBA{red, red}))
+	sameImage(t, "frame 1", frames[1].Image, pixels([]color.NRGBA{red, red}, []color.NRGBA{red, blue}))
+	sameImage(t, "frame 2", frames[2].Image, pixels([]color.NRGBA{green, red}, []color.NRGBA{red, none}))
+}
+
+func TestParse(t *testing.T) {
+	if fit, err := ParseFit("fill"); err != nil || fit != FitCover {
+		t.Errorf("ParseFit(fill) = %v, %v", fit, err)
+	}
+	if filter, err := ParseFilter("nearest"); err != nil || filter != FilterNearest {
+		t.Errorf("ParseFilter(nearest) = %v, %v", filter, err)
+	}
+	if _, err := ParseFit("zoom"); err == nil {
+		t.Error("ParseFit accepted zoom")
+	}
+	if _, err := ParseFilter("bicubic"); err == nil {
+		t.Error("ParseFilter accepted bicubic")
+	}
+}
@@ -0,0 +1,147 @@
+// Package raster turns lines, rectangles and ellipses into the cells of a grid
+// they cover
+package raster
+
+import "image"
+
+// Line the cells from a to b, both included, by Bresenham's algorithm
+func Line(a, b image.Point) []image.Point {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := sign(b.X-a.X), sign(b.Y-a.Y)
+	var points []image.Point
+	err := dx + dy
+	for p := a; ; {
+		points = append(points, p)
+		if p == b {
+			return points
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p.Y += sy
+		}
+	}
+}
+
+// Rect the cells of the rectangle with corners a and b, its outline or all of it
+func Rect(a, b image.Point, filled bool) []image.Point {
+	r := image.Rectangle{Min: a, Max: b}.Canon()
+	var points []image.Point
+	for y := r.Min.Y; y <= r.Max.Y; y++ {
+		for x := r.Min.X; x <= r.Max.X; x++ {
+			if filled || y == r.Min.Y || y == r.Max.Y || x == r.Min.X || x == r.Max.X {
+				points = append(points, image.Pt(x, y))
+			}
+		}
+	}
+	return points
+}
+
+// Ellipse the cells of the ellipse fitting the rectangle with corners a and b,
+// its outline or all of it.  The outline is found with the midpoint algorithm
+// in integers, following Zingl's form that also fits boxes of even size.
+func Ellipse(a, b image.Point, filled bool) []image.Point {
+	r := image.Rectangle{Min: a, Max: b}.Canon()
+	x0, y0, x1, y1 := r.Min.X, r.Min.Y, r.Max.X, r.Max.Y
+	w, h := int64(x1-x0), int64(y1-y0)
+	odd := h & 1
+	dx, dy := 4*(1-w)*h*h, 4*(odd+1)*w*w // error increments
+	err := dx + dy + odd*w*w
+
+	// each row holds the leftmost and rightmost cell of the outline on it
+	outline, rows := map[image.Point]bool{}, map[int][2]int{}
+	plot := func(x, y int) {
+		outline[image.Pt(x, y)] = true
+		span, ok := rows[y]
+		if !ok {
+			span = [2]int{x, x}
+		}
+		if x < span[0] {
+			span[0] = x
+		}
+		if x > span[1] {
+			span[1] = x
+		}
+		rows[y] = span
+	}
+	y0 += int(h+1) / 2
+	y1 = y0 - int(odd)
+	stepX, stepY := 8*h*h, 8*w*w
+	for x0 <= x1 {
+		plot(x1, y0)
+		plot(x0, y0)
+		plot(x0, y1)
+		plot(x1, y1)
+		e2 := 2 * err
+		if e2 <= dy {
+			y0++
+			y1--
+			dy += stepY
+			err += dy
+		}
+		if e2 >= dx || 2*err > dy {
+			x0++
+			x1--
+			dx += stepX
+			err += dx
+		}
+	}
+	// very flat ellipses stop before reaching their tips
+	for int64(y0-y1) <= h {
+		plot(x0-1, y0)
+		plot(x1+1, y0)
+		plot(x0-1, y1)
+		plot(x1+1, y1)
+		y0++
+		y1--
+	}
+
+	var points []image.Point
+	for y := r.Min.Y; y <= r.Max.Y; y++ {
+		span, ok := rows[y]
+		if !ok {
+			continue
+		}
+		for x := span[0]; x <= span[1]; x++ {
+			if filled || outline[image.Pt(x, y)] {
+				points = append(points, image.Pt(x, y))
+			}
+		}
+	}
+	return points
+}
+
+// Disc the cells within radius of center, rounded out so small discs aren't
+// diamonds; a radius of 0 is the center alone
+func Disc(center image.Point, radius int) []image.Point {
+	var points []image.Point
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius+radius/2 {
+				points = append(points, center.Add(image.Pt(x, y)))
+			}
+		}
+	}
+	return points
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,168 @@
+package raster
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// picture draws points as rows of # on a w x h grid of .
+func picture(points []image.Point, w, h int) string {
+	rows := make([][]byte, h)
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat(".", w))
+	}
+	for _, p := range points {
+		rows[p.Y][p.X] = '#'
+	}
+	lines := make([]string, h)
+	for y, row := range rows {
+		lines[y] = string(row)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func lines(rows ...string) string {
+	return strings.Join(rows, "\n")
+}
+
+func noRepeats(t *testing.T, name string, points []image.Point) {
+	t.Helper()
+	seen := map[image.Point]bool{}
+	for _, p := range points {
+		if seen[p] {
+			t.Errorf("%s: %v twice", name, p)
+		}
+		seen[p] = true
+	}
+}
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b image.Point
+		want []image.Point
+	}{
+		{"a point", image.Pt(2, 3), image.Pt(2, 3), []image.Point{{2, 3}}},
+		{"gentle slope", image.Pt(0, 0), image.Pt(5, 2), []image.Point{{0, 0}, {1, 0}, {2, 1}, {3, 1}, {4, 2}, {5, 2}}},
+		{"steep and backwards", image.Pt(1, 3), image.Pt(0, 0), []image.Point{{1, 3}, {1, 2}, {0, 1}, {0, 0}}},
+		{"diagonal", image.Pt(0, 2), image.Pt(2, 0), []image.Point{{0, 2}, {1, 1}, {2, 0}}},
+	}
+	for _, tt := range tests {
+		if got := Line(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Line(%v, %v) = %v, want %v", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRect(t *testing.T) {
+	outline := Rect(image.Pt(3, 2), image.Pt(0, 0), false)
+	if got, want := picture(outline, 5, 4), lines(
+		"####.",
+		"#..#.",
+		"####.",
+		".....",
+	); got != want {
+		t.Errorf("outline\n%s\nwant\n%s", got, want)
+	}
+	noRepeats(t, "outline", outline)
+
+	filled := Rect(image.Pt(1, 1), image.Pt(3, 2), true)
+	if got, want := picture(filled, 5, 4), lines(
+		".....",
+		".###.",
+		".###.",
+		".....",
+	); got != want {
+		t.Errorf("filled\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEllipse(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   image.Point
+		filled bool
+		want   string
+	}{
+		{"circle", image.Pt(0, 0), image.Pt(4, 4), false, lines(
+			".###.",
+			"#...#",
+			"#...#",
+			"#...#",
+			".###.",
+		)},
+		{"filled circle", image.Pt(4, 4), image.Pt(0, 0), true, lines(
+			".###.",
+			"#####",
+			"#####",
+			"#####",
+			".###.",
+		)},
+		{"even sized", image.Pt(0, 0), image.Pt(5, 3), false, lines(
+			".####.",
+			"#....#",
+			"#....#",
+			".####.",
+		)},
+		{"wide", image.Pt(0, 0), image.Pt(8, 2), false, lines(
+			"..#####..",
+			"##.....##",
+			"..#####..",
+		)},
+		{"flat", image.Pt(0, 1), image.Pt(4, 1), false, lines(
+			".....",
+			"#####",
+			".....",
+		)},
+		{"a point", image.Pt(2, 1), image.Pt(2, 1), false, lines(
+			".....",
+			"..#..",
+			".....",
+		)},
+	}
+	for _, tt := range tests {
+		points := Ellipse(tt.a, tt.b, tt.filled)
+		w := len(strings.Split(tt.want, "\n")[0])
+		h := strings.Count(tt.want, "\n") + 1
+		if got := picture(points, w, h); got != tt.want {
+			t.Errorf("%s\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+		noRepeats(t, tt.name, points)
+	}
+}
+
+func TestDisc(t *testing.T) {
+	tests := []struct {
+		radius int
+		want   string
+	}{
+		{0, lines(
+			".....",
+			".....",
+			"..#..",
+			".....",
+			".....",
+		)},
+		{1, lines(
+			".....",
+			"..#..",
+			".###.",
+			"..#..",
+			".....",
+		)},
+		{2, lines(
+			".###.",
+			"#####",
+			"#####",
+			"#####",
+			".###.",
+		)},
+	}
+	for _, tt := range tests {
+		if got := picture(Disc(image.Pt(2, 2), tt.radius), 5, 5); got != tt.want {
+			t.Errorf("radius %d\n%s\nwant\n%s", tt.radius, got, tt.want)
+		}
+	}
+}
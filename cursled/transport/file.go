@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"io"
+	"os"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+)
+
+// File writes frames to a file, as an indexed recording or a bare frame stream
+type File struct {
+	file      *os.File
+	w         io.Writer
+	recording *frame.Writer
+}
+
+// CreateFile truncates or creates path.  With a header the frames are written as
+// a recording with a seek index, otherwise as a bare stream; Version1 frames can
+// only be written as a bare stream.
+func CreateFile(path string, header *frame.ContainerHeader) (*File, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{file: file, w: file}
+	if header != nil {
+		if f.recording, err = frame.NewWriter(file, *header); err != nil {
+			file.Close()
+			return nil, err
+		}
+		f.w = f.recording
+	}
+	return f, nil
+}
+
+// Write appends one frame
+func (f *File) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+// Close writes the recording's index, if there is one, and closes the file
+func (f *File) Close() error {
+	if f.recording != nil {
+		if err := f.recording.Close(); err != nil {
+			f.file.Close()
+			return err
+		}
+	}
+	return f.file.Close()
+}
+
+func (f *File) String() string {
+	return "file " + f.file.Name()
+}
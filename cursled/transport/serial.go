@@ -0,0 +1,92 @@
+// Package transport carries encoded frames from the drawing tools to the LEDs
+package transport
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parity of each character sent over a serial line
+type Parity byte
+
+// Parity settings, named as in the usual 8N1 shorthand
+const (
+	ParityNone Parity = 'N'
+	ParityEven Parity = 'E'
+	ParityOdd  Parity = 'O'
+)
+
+// FlowControl how the sender and receiver pace a serial line
+type FlowControl string
+
+// Flow control settings
+const (
+	FlowNone    FlowControl = "none"
+	FlowRTSCTS  FlowControl = "rtscts"  // hardware, on the RTS and CTS lines
+	FlowXONXOFF FlowControl = "xonxoff" // software, with XON and XOFF characters
+)
+
+// DefaultBaud the rate the ESP8266 sketch listens at
+const DefaultBaud = 921600
+
+// SerialConfig how to open a serial device
+type SerialConfig struct {
+	Device      string
+	Baud        int
+	DataBits    int // 5 to 8
+	Parity      Parity
+	StopBits    int // 1 or 2
+	FlowControl FlowControl
+}
+
+// DefaultSerialConfig the 8N1 settings the ESP8266 sketch expects, without flow control
+func DefaultSerialConfig(device string) SerialConfig {
+	return SerialConfig{
+		Device:      device,
+		Baud:        DefaultBaud,
+		DataBits:    8,
+		Parity:      ParityNone,
+		StopBits:    1,
+		FlowControl: FlowNone,
+	}
+}
+
+// ParseFraming sets the data bits, parity and stop bits of c from shorthand like "8N1"
+func (c *SerialConfig) ParseFraming(framing string) error {
+	framing = strings.ToUpper(framing)
+	if len(framing) != 3 || framing[0] < '5' || framing[0] > '8' || framing[2] < '1' || framing[2] > '2' {
+		return fmt.Errorf("transport: framing %q is not like 8N1", framing)
+	}
+	parity := Parity(framing[1])
+	if parity != ParityNone && parity != ParityEven && parity != ParityOdd {
+		return fmt.Errorf("transport: framing %q has unknown parity %q", framing, framing[1])
+	}
+	c.DataBits, c.Parity, c.StopBits = int(framing[0]-'0'), parity, int(framing[2]-'0')
+	return nil
+}
+
+// Framing the shorthand for the data bits, parity and stop bits of c, e.g. "8N1"
+func (c SerialConfig) Framing() string {
+	return fmt.Sprintf("%d%c%d", c.DataBits, c.Parity, c.StopBits)
+}
+
+func (c SerialConfig) validate() error {
+	if c.Device == "" {
+		return fmt.Errorf("transport: no serial device given")
+	}
+	if c.Baud <= 0 {
+		return fmt.Errorf("transport: baud %d must be positive", c.Baud)
+	}
+	if err := (&SerialConfig{}).ParseFraming(c.Framing()); err != nil {
+		return err
+	}
+	switch c.FlowControl {
+	case FlowNone, FlowRTSCTS, FlowXONXOFF:
+		return nil
+	}
+	return fmt.Errorf("transport: unknown flow control %q", c.FlowControl)
+}
+
+func (c SerialConfig) String() string {
+	return fmt.Sprintf("%s %d %s flow:%s", c.Device, c.Baud, c.Framing(), c.FlowControl)
+}
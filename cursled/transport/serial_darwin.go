@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	getTermios = unix.TIOCGETA
+	setTermios = unix.TIOCSETA
+
+	// iossiospeed the IOSSIOSPEED ioctl, _IOW('T', 2, speed_t), which sets the
+	// rates the serial drivers can't be given through the termios
+	iossiospeed = 0x80085402
+)
+
+// baudRates the rates macOS takes in the termios, as the rate itself
+var baudRates = map[int]uint64{
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+	230400: unix.B230400,
+}
+
+// setBaud sets the standard rates in t; any other is set by setCustomBaud once
+// t has been applied, so t is left at 9600 for it
+func setBaud(t *unix.Termios, baud int) error {
+	speed, ok := baudRates[baud]
+	if !ok {
+		speed = unix.B9600
+	}
+	t.Ispeed, t.Ospeed = speed, speed
+	return nil
+}
+
+// setCustomBaud sets the rates setBaud can't on the device open as fd
+func setCustomBaud(fd, baud int) error {
+	if _, ok := baudRates[baud]; ok {
+		return nil
+	}
+	speed := uint64(baud) // speed_t
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), iossiospeed, uintptr(unsafe.Pointer(&speed))); errno != 0 {
+		return fmt.Errorf("transport: unsupported baud %d: %w", baud, errno)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	getTermios = unix.TCGETS
+	setTermios = unix.TCSETS
+)
+
+var baudRates = map[int]uint32{
+	9600:    unix.B9600,
+	19200:   unix.B19200,
+	38400:   unix.B38400,
+	57600:   unix.B57600,
+	115200:  unix.B115200,
+	230400:  unix.B230400,
+	460800:  unix.B460800,
+	500000:  unix.B500000,
+	576000:  unix.B576000,
+	921600:  unix.B921600,
+	1000000: unix.B1000000,
+	1152000: unix.B1152000,
+	1500000: unix.B1500000,
+	2000000: unix.B2000000,
+	3000000: unix.B3000000,
+	4000000: unix.B4000000,
+}
+
+func setBaud(t *unix.Termios, baud int) error {
+	speed, ok := baudRates[baud]
+	if !ok {
+		return fmt.Errorf("transport: unsupported baud %d", baud)
+	}
+	t.Cflag &^= unix.CBAUD | unix.CBAUDEX
+	t.Cflag |= speed
+	t.Ispeed, t.Ospeed = speed, speed
+	return nil
+}
+
+// setCustomBaud does nothing on Linux, where setBaud sets every supported rate
+func setCustomBaud(fd, baud int) error {
+	return nil
+}
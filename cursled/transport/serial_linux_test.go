@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"golang.org/x/sys/unix"
+)
+
+// openPTY returns the master side of a new pseudo-terminal and the path of its
+// slave, which stands in for the board's serial device
+func openPTY(t *testing.T) (*os.File, string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminals:", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestSerialFrames(t *testing.T) {
+	master, device := openPTY(t)
+	serial, err := OpenSerial(DefaultSerialConfig(device))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serial.Close()
+
+	// carriage returns and newlines in the frames would be translated by a
+	// terminal that was not put in raw mode
+	f := frame.Frame{LEDs: []frame.LEDInfo{
+		{Row: 0, Column: 0, Red: '\n', Green: '\r', Blue: 0x03, Brightness: 0x7F},
+		{Row: 0, Column: 1, Red: 0x11, Green: 0x13, Blue: 0x1A, Brightness: 0xFF},
+	}}
+	var want bytes.Buffer
+	if err := frame.NewEncoder(&want).Encode(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := frame.NewEncoder(serial).Encode(f); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, want.Len())
+	master.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(master, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("board got % x\nwant % x", got, want.Bytes())
+	}
+
+	decoded, err := frame.NewDecoder(bytes.NewReader(got)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.LEDs) != 2 || decoded.LEDs[0] != f.LEDs[0] {
+		t.Errorf("decoded %+v, want %+v", decoded.LEDs, f.LEDs)
+	}
+}
+
+func TestSerialFraming(t *testing.T) {
+	tests := []struct {
+		framing string
+		flow    FlowControl
+		cflag   uint32 // of CSIZE, PARENB, PARODD, CSTOPB and CRTSCTS
+		iflag   uint32 // of IXON and IXOFF
+	}{
+		{"8N1", FlowNone, unix.CS8, 0},
+		{"7E2", FlowRTSCTS, unix.CS7 | unix.PARENB | unix.CSTOPB | unix.CRTSCTS, 0},
+		{"5O1", FlowXONXOFF, unix.CS5 | unix.PARENB | unix.PARODD, unix.IXON | unix.IXOFF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.framing, func(t *testing.T) {
+			config := DefaultSerialConfig("/dev/null")
+			config.FlowControl = tt.flow
+			if err := config.ParseFraming(tt.framing); err != nil {
+				t.Fatal(err)
+			}
+			// start from a cooked terminal with everything makeRaw should clear
+			termios := &unix.Termios{
+				Iflag: unix.ICRNL | unix.IXON,
+				Oflag: unix.OPOST | unix.ONLCR,
+				Lflag: unix.ECHO | unix.ICANON | unix.ISIG,
+				Cflag: unix.CS8 | unix.CRTSCTS | unix.PARENB,
+			}
+			if err := makeRaw(termios, config); err != nil {
+				t.Fatal(err)
+			}
+			if got := termios.Cflag & (unix.CSIZE | unix.PARENB | unix.PARODD | unix.CSTOPB | unix.CRTSCTS); got != tt.cflag {
+				t.Errorf("cflag = %o, want %o", got, tt.cflag)
+			}
+			if got := termios.Iflag & (unix.IXON | unix.IXOFF | unix.ICRNL); got != tt.iflag {
+				t.Errorf("iflag = %o, want %o", got, tt.iflag)
+			}
+			if termios.Oflag&unix.OPOST != 0 || termios.Lflag&(unix.ECHO|unix.ICANON|unix.ISIG) != 0 {
+				t.Errorf("terminal left cooked: %+v", termios)
+			}
+			if termios.Cflag&unix.CBAUD != unix.B921600 {
+				t.Errorf("baud bits = %o, want %o", termios.Cflag&unix.CBAUD, unix.B921600)
+			}
+		})
+	}
+}
+
+func TestSerialSettings(t *testing.T) {
+	// Linux ptys keep the baud and flow control they are given but always run 8N1
+	_, device := openPTY(t)
+	config := DefaultSerialConfig(device)
+	config.Baud = 115200
+	config.FlowControl = FlowRTSCTS
+	serial, err := OpenSerial(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serial.Close()
+
+	termios, err := unix.IoctlGetTermios(int(serial.file.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if termios.Cflag&unix.CBAUD != unix.B115200 {
+		t.Errorf("baud bits = %o, want %o", termios.Cflag&unix.CBAUD, unix.B115200)
+	}
+	if termios.Cflag&unix.CRTSCTS == 0 {
+		t.Error("RTS/CTS flow control not set")
+	}
+}
+
+func TestSerialConfigRejects(t *testing.T) {
+	for _, framing := range []string{"", "8N", "9N1", "8X1", "8N3"} {
+		if err := (&SerialConfig{}).ParseFraming(framing); err == nil {
+			t.Errorf("ParseFraming(%q) accepted", framing)
+		}
+	}
+
+	bad := DefaultSerialConfig("/dev/null")
+	bad.Baud = 12345
+	if _, err := OpenSerial(bad); err == nil {
+		t.Error("OpenSerial accepted an unsupported baud")
+	}
+	bad = DefaultSerialConfig("/dev/null")
+	bad.FlowControl = "dsrdtr"
+	if _, err := OpenSerial(bad); err == nil {
+		t.Error("OpenSerial accepted unknown flow control")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+
+package transport
+
+import (
+	"errors"
+	"runtime"
+)
+
+// Serial a serial device; only supported on Linux and macOS
+type Serial struct{}
+
+// OpenSerial fails on platforms without termios support
+func OpenSerial(config SerialConfig) (*Serial, error) {
+	return nil, errors.New("transport: serial ports are not supported on " + runtime.GOOS)
+}
+
+func (s *Serial) Write(p []byte) (int, error) { return 0, errors.ErrUnsupported }
+func (s *Serial) Read(p []byte) (int, error)  { return 0, errors.ErrUnsupported }
+func (s *Serial) Close() error                { return nil }
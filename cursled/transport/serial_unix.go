@@ -0,0 +1,111 @@
+//go:build linux || darwin
+
+package transport
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Serial a serial device set up to pass frames through untouched
+type Serial struct {
+	file   *os.File
+	config SerialConfig
+}
+
+// OpenSerial opens the device in config in raw mode with its baud, framing and flow control
+func OpenSerial(config SerialConfig) (*Serial, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	// without O_NONBLOCK the open waits for carrier detect on some adapters
+	file, err := os.OpenFile(config.Device, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	s := &Serial{file: file, config: config}
+	if err := s.configure(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("transport: setting up %s: %w", config.Device, err)
+	}
+	return s, nil
+}
+
+func (s *Serial) configure() error {
+	fd := int(s.file.Fd())
+	t, err := unix.IoctlGetTermios(fd, getTermios)
+	if err != nil {
+		return err
+	}
+	if err := makeRaw(t, s.config); err != nil {
+		return err
+	}
+	if err := unix.IoctlSetTermios(fd, setTermios, t); err != nil {
+		return err
+	}
+	if err := setCustomBaud(fd, s.config.Baud); err != nil {
+		return err
+	}
+	// writes block from here on so a slow line paces the frames rather than dropping them
+	return unix.SetNonblock(fd, false)
+}
+
+// makeRaw sets up t for config, passing bytes through untouched
+func makeRaw(t *unix.Termios, config SerialConfig) error {
+	// raw mode: no echo, signals, line editing or translation of the bytes sent
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF | unix.IXANY
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.PARODD | unix.CSTOPB | unix.CRTSCTS
+	t.Cflag |= unix.CREAD | unix.CLOCAL
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+
+	switch config.DataBits {
+	case 5:
+		t.Cflag |= unix.CS5
+	case 6:
+		t.Cflag |= unix.CS6
+	case 7:
+		t.Cflag |= unix.CS7
+	default:
+		t.Cflag |= unix.CS8
+	}
+	switch config.Parity {
+	case ParityEven:
+		t.Cflag |= unix.PARENB
+	case ParityOdd:
+		t.Cflag |= unix.PARENB | unix.PARODD
+	}
+	if config.StopBits == 2 {
+		t.Cflag |= unix.CSTOPB
+	}
+	switch config.FlowControl {
+	case FlowRTSCTS:
+		t.Cflag |= unix.CRTSCTS
+	case FlowXONXOFF:
+		t.Iflag |= unix.IXON | unix.IXOFF
+	}
+	return setBaud(t, config.Baud)
+}
+
+// Write sends p down the line, blocking until the device has taken all of it
+func (s *Serial) Write(p []byte) (int, error) {
+	return s.file.Write(p)
+}
+
+// Read reads whatever the device has sent back, e.g. debug output from the board
+func (s *Serial) Read(p []byte) (int, error) {
+	return s.file.Read(p)
+}
+
+// Close closes the device
+func (s *Serial) Close() error {
+	return s.file.Close()
+}
+
+func (s *Serial) String() string {
+	return "serial " + s.config.String()
+}
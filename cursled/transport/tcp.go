@@ -0,0 +1,209 @@
+package transport
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// TCP sends frames to a receiver over a TCP connection.  While the receiver is
+// away frames are dropped rather than held up, and the connection is redialled
+// in the background with a growing backoff.
+type TCP struct {
+	addr        string
+	mu          sync.Mutex
+	conn        net.Conn
+	dialing     bool
+	reconnected bool
+	closed      chan struct{}
+	backoff     backoff
+	dropped     uint64
+
+	// MinBackoff how long to wait after the first failed dial
+	MinBackoff time.Duration
+	// MaxBackoff the longest wait between dials
+	MaxBackoff time.Duration
+	// Timeout how long a dial or a frame's write may take before the connection is given up
+	Timeout time.Duration
+}
+
+// DialTCP returns a TCP transport that starts connecting to addr straight away
+func DialTCP(addr string) *TCP {
+	t := &TCP{
+		addr:       addr,
+		closed:     make(chan struct{}),
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+		Timeout:    time.Second,
+	}
+	t.mu.Lock()
+	t.redial()
+	t.mu.Unlock()
+	return t
+}
+
+// Write sends one frame, or drops it while there is no connection
+func (t *TCP) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		t.dropped++
+		t.redial()
+		return len(p), nil
+	}
+	t.conn.SetWriteDeadline(time.Now().Add(t.Timeout))
+	if _, err := t.conn.Write(p); err != nil {
+		// the receiver resyncs on the next frame's sentinel if part of this one got through
+		t.conn.Close()
+		t.conn = nil
+		t.dropped++
+		t.redial()
+	}
+	return len(p), nil
+}
+
+// Dropped how many frames were not sent for want of a connection
+func (t *TCP) Dropped() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// Reconnected reports whether a new connection was made since it was last called
+func (t *TCP) Reconnected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reconnected := t.reconnected
+	t.reconnected = false
+	return reconnected
+}
+
+// redial starts dialling in the background unless already doing so; t.mu must be held
+func (t *TCP) redial() {
+	if t.dialing {
+		return
+	}
+	select {
+	case <-t.closed:
+		return
+	default:
+	}
+	t.dialing = true
+	go t.dial()
+}
+
+func (t *TCP) dial() {
+	for {
+		conn, err := net.DialTimeout("tcp", t.addr, t.Timeout)
+		t.mu.Lock()
+		select {
+		case <-t.closed:
+			t.dialing = false
+			t.mu.Unlock()
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		default:
+		}
+		if err == nil {
+			t.conn, t.dialing, t.reconnected = conn, false, true
+			t.backoff.reset()
+			t.mu.Unlock()
+			return
+		}
+		t.backoff.min, t.backoff.max = t.MinBackoff, t.MaxBackoff
+		wait := t.backoff.wait()
+		t.mu.Unlock()
+
+		select {
+		case <-t.closed:
+			t.mu.Lock()
+			t.dialing = false
+			t.mu.Unlock()
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Close stops redialling and closes the connection
+func (t *TCP) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.closed:
+		return nil
+	default:
+	}
+	close(t.closed)
+	if t.conn != nil {
+		return t.conn.Close()
+	}
+	return nil
+}
+
+func (t *TCP) String() string {
+	return "tcp " + t.addr
+}
+
+// TCPSource reads the frames sent by each sender that connects, one sender at a time
+type TCPSource struct {
+	listener net.Listener
+	mu       sync.Mutex
+	conn     net.Conn
+}
+
+// ListenTCP listens on addr for senders
+func ListenTCP(addr string) (*TCPSource, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &TCPSource{listener: listener}, nil
+}
+
+// Addr the address being listened on, useful when addr gave port 0
+func (s *TCPSource) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Read reads from the current sender, waiting for the next one when it goes away
+func (s *TCPSource) Read(p []byte) (int, error) {
+	for {
+		s.mu.Lock()
+		conn := s.conn
+		s.mu.Unlock()
+		if conn == nil {
+			var err error
+			if conn, err = s.listener.Accept(); err != nil {
+				return 0, err
+			}
+			s.mu.Lock()
+			s.conn = conn
+			s.mu.Unlock()
+		}
+
+		n, err := conn.Read(p)
+		if err != nil {
+			conn.Close()
+			s.mu.Lock()
+			s.conn = nil
+			s.mu.Unlock()
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close stops listening and drops the current sender; a blocked Read returns an error
+func (s *TCPSource) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+)
+
+// waitFor polls cond until it holds or a few seconds pass
+func waitFor(t *testing.T, what string, cond func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("timed out waiting for", what)
+}
+
+func TestTCPFrames(t *testing.T) {
+	source, err := ListenTCP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	sink := DialTCP(source.Addr().String())
+	defer sink.Close()
+	waitFor(t, "connection", sink.Reconnected)
+
+	enc := frame.NewEncoder(sink)
+	for i := 0; i < 3; i++ {
+		if err := enc.Encode(frame.Frame{LEDs: []frame.LEDInfo{{Column: uint16(i), Brightness: 0xFF}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dec := frame.NewDecoder(source)
+	for i := 0; i < 3; i++ {
+		f, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Header.Sequence != uint32(i) {
+			t.Errorf("frame %d has sequence %d", i, f.Header.Sequence)
+		}
+	}
+}
+
+func TestTCPReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	sink := DialTCP(addr)
+	sink.MinBackoff, sink.MaxBackoff = 10*time.Millisecond, 20*time.Millisecond
+	defer sink.Close()
+
+	// nobody is listening so frames are dropped without blocking
+	if n, err := sink.Write([]byte("frame")); n != 5 || err != nil {
+		t.Errorf("Write() = %d, %v while disconnected", n, err)
+	}
+	if sink.Dropped() == 0 {
+		t.Error("expected a dropped frame")
+	}
+
+	listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip("could not listen again on", addr, err)
+	}
+	defer listener.Close()
+	waitFor(t, "reconnect", sink.Reconnected)
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sink.Write([]byte("hello"))
+	got := make([]byte, 5)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(got); err != nil || string(got) != "hello" {
+		t.Errorf("receiver got %q, %v", got, err)
+	}
+	if sink.Reconnected() {
+		t.Error("Reconnected() reported the same connection twice")
+	}
+}
@@ -0,0 +1,215 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/layout"
+)
+
+// Transport a sink for encoded frames.  Each Write carries one whole frame, as
+// a frame.Encoder writes them.
+type Transport interface {
+	io.Writer
+	io.Closer
+}
+
+// Reconnector is implemented by transports whose receiver can go away and come
+// back; the sender should follow a reconnect with a key frame
+type Reconnector interface {
+	// Reconnected reports whether a new connection was made since it was last called
+	Reconnected() bool
+}
+
+// Display describes the LEDs frames are sent to, the grid they show, the order
+// they are chained in and the version of the frames sent to them
+type Display struct {
+	Header  frame.ContainerHeader // grid size, pixel format and fps
+	Layout  layout.Layout         // where each LED along a strip sits on the grid; nil for row by row
+	Version uint8                 // of the frames sent; file outputs of Version1 frames are bare streams
+}
+
+// Opener opens a sink for a URL with a scheme added by Register
+type Opener func(u *url.URL, display Display) (Transport, error)
+
+var openers = map[string]Opener{}
+
+// Register makes Open accept URLs with scheme, e.g. for output drivers in other packages
+func Register(scheme string, open Opener) {
+	openers[scheme] = open
+}
+
+// Open opens the sink named by rawURL:
+//
+//	file:///path/to/recording       a recording of display; add ?raw=true for a bare frame stream,
+//	                                as Version1 frames always are since they carry no timing
+//	serial:///dev/ttyUSB0?baud=921600&framing=8N1&flow=none
+//	tcp://host:port                 reconnecting with backoff when the receiver goes away
+//	udp://host:port?mtu=1472        one frame per datagram, fragmented when bigger
+//
+// or any scheme added with Register.  A URL without a scheme is taken as a file
+// path.  The frames written to the file, serial, TCP and UDP sinks have their
+// LEDs moved to where display's layout says they are wired.
+func Open(rawURL string, display Display) (Transport, error) {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if open, ok := openers[u.Scheme]; ok {
+		return open(u, display)
+	}
+	t, err := openStream(u, display)
+	if err != nil {
+		return nil, err
+	}
+	return Wire(t, display), nil
+}
+
+// openStream opens the sinks of the frame stream itself
+func openStream(u *url.URL, display Display) (Transport, error) {
+	query := u.Query()
+	switch u.Scheme {
+	case "file":
+		header := &display.Header
+		if raw, _ := strconv.ParseBool(query.Get("raw")); raw || display.Version == frame.Version1 {
+			header = nil
+		}
+		file, err := CreateFile(filePath(u), header)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	case "serial":
+		config, err := serialConfigFromURL(u)
+		if err != nil {
+			return nil, err
+		}
+		serial, err := OpenSerial(config)
+		if err != nil {
+			return nil, err
+		}
+		return serial, nil
+	case "tcp":
+		return DialTCP(u.Host), nil
+	case "udp":
+		udp, err := DialUDP(u.Host)
+		if err != nil {
+			return nil, err
+		}
+		if mtu := query.Get("mtu"); mtu != "" {
+			if udp.MaxDatagram, err = strconv.Atoi(mtu); err != nil {
+				udp.Close()
+				return nil, fmt.Errorf("transport: bad mtu %q", mtu)
+			}
+		}
+		return udp, nil
+	}
+	return nil, fmt.Errorf("transport: unknown scheme %q in %s", u.Scheme, u)
+}
+
+// Listen opens the source of frames named by rawURL, to read with a frame.Decoder:
+//
+//	file:///path/to/recording  a recording or bare frame stream
+//	serial:///dev/ttyUSB0      frames sent back by the board
+//	tcp://:port                frames from each sender that connects, one at a time
+//	udp://:port                frames sent as datagrams, reassembled from fragments
+func Listen(rawURL string) (io.ReadCloser, error) {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "file":
+		file, err := os.Open(filePath(u))
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	case "serial":
+		config, err := serialConfigFromURL(u)
+		if err != nil {
+			return nil, err
+		}
+		serial, err := OpenSerial(config)
+		if err != nil {
+			return nil, err
+		}
+		return serial, nil
+	case "tcp":
+		source, err := ListenTCP(u.Host)
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	case "udp":
+		source, err := ListenUDP(u.Host)
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	}
+	return nil, fmt.Errorf("transport: unknown scheme %q in %s", u.Scheme, rawURL)
+}
+
+func parseURL(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		return &url.URL{Scheme: "file", Path: rawURL}, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("transport: %w", err)
+	}
+	return u, nil
+}
+
+// filePath allows relative paths written as file://name as well as file:///abs/name
+func filePath(u *url.URL) string {
+	return u.Host + u.Path
+}
+
+func serialConfigFromURL(u *url.URL) (SerialConfig, error) {
+	query := u.Query()
+	config := DefaultSerialConfig(u.Host + u.Path)
+	if baud := query.Get("baud"); baud != "" {
+		var err error
+		if config.Baud, err = strconv.Atoi(baud); err != nil {
+			return config, fmt.Errorf("transport: bad baud %q", baud)
+		}
+	}
+	if framing := query.Get("framing"); framing != "" {
+		if err := config.ParseFraming(framing); err != nil {
+			return config, err
+		}
+	}
+	if flow := query.Get("flow"); flow != "" {
+		config.FlowControl = FlowControl(flow)
+	}
+	return config, nil
+}
+
+// backoff doubles the wait after each failed attempt, up to max
+type backoff struct {
+	min, max time.Duration
+	next     time.Duration
+}
+
+func (b *backoff) wait() time.Duration {
+	if b.next < b.min {
+		b.next = b.min
+	}
+	wait := b.next
+	if b.next *= 2; b.next > b.max {
+		b.next = b.max
+	}
+	return wait
+}
+
+func (b *backoff) reset() {
+	b.next = b.min
+}
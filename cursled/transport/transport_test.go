@@ -0,0 +1,145 @@
+package transport
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/layout"
+)
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	header := frame.ContainerHeader{Rows: 2, Columns: 3, FPS: 30}
+	tests := []struct {
+		name      string
+		url       string
+		version   uint8
+		recording bool
+	}{
+		{"bare path", filepath.Join(dir, "a.data"), 0, true},
+		{"file URL", "file://" + filepath.Join(dir, "b.data"), 0, true},
+		{"raw stream", "file://" + filepath.Join(dir, "c.data") + "?raw=true", 0, false},
+		{"version 1", "file://" + filepath.Join(dir, "d.data"), frame.Version1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, err := Open(tt.url, Display{Header: header, Version: tt.version})
+			if err != nil {
+				t.Fatal(err)
+			}
+			enc := frame.NewEncoder(sink)
+			if tt.version != 0 {
+				enc.Version = tt.version
+			}
+			for i := 0; i < 3; i++ {
+				if err := enc.Encode(frame.Frame{LEDs: []frame.LEDInfo{{Column: uint16(i), Red: 0xFF, Brightness: 0xFF}}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			source, err := Listen(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer source.Close()
+			recording, err := frame.NewReader(source.(io.ReadSeeker))
+			if tt.recording {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if recording.Header() != header || recording.Len() != 3 {
+					t.Errorf("recording %+v with %d frames, want %+v with 3", recording.Header(), recording.Len(), header)
+				}
+			} else if err != frame.ErrNotRecording {
+				t.Errorf("NewReader(raw stream) = %v, want ErrNotRecording", err)
+			}
+		})
+	}
+}
+
+func TestOpenWiresLEDs(t *testing.T) {
+	source, err := ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	source.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	serpentine, err := layout.Wiring{Rows: 2, Columns: 3, Serpentine: true}.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := Open("udp://"+source.Addr().String(), Display{Header: frame.ContainerHeader{Rows: 2, Columns: 3}, Layout: serpentine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sent := frame.Frame{LEDs: []frame.LEDInfo{
+		{Row: 0, Column: 0, Red: 1, Brightness: 0xFF},
+		{Row: 1, Column: 0, Red: 2, Brightness: 0xFF},
+		{Row: 1, Column: 2, Red: 3, Brightness: 0xFF},
+	}}
+	if err := frame.NewEncoder(sink).Encode(sent); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := frame.NewDecoder(source).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second row runs back from the end of the first
+	want := [][2]uint16{{0, 0}, {1, 2}, {1, 0}}
+	if len(got.LEDs) != len(want) {
+		t.Fatalf("received %d LEDs, want %d", len(got.LEDs), len(want))
+	}
+	for i, led := range got.LEDs {
+		if [2]uint16{led.Row, led.Column} != want[i] || led.Red != sent.LEDs[i].Red {
+			t.Errorf("LED %d at %d,%d red %d, want %v red %d", i, led.Row, led.Column, led.Red, want[i], sent.LEDs[i].Red)
+		}
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	for _, url := range []string{
+		"ftp://example.com/leds",
+		"serial:///dev/null?baud=fast",
+		"serial:///dev/null?framing=9Z9",
+		"udp://127.0.0.1:1?mtu=big",
+		"serial:///nonexistent/tty",
+		"file:///nonexistent/dir/leds.data",
+	} {
+		sink, err := Open(url, Display{})
+		if err == nil {
+			sink.Close()
+			t.Errorf("Open(%q) accepted", url)
+		} else if sink != nil {
+			t.Errorf("Open(%q) returned %#v with its error, want nil", url, sink)
+		}
+	}
+	for _, url := range []string{"serial:///nonexistent/tty", "file:///nonexistent/leds.data"} {
+		if source, err := Listen(url); err == nil || source != nil {
+			t.Errorf("Listen(%q) = %#v, %v, want nil and an error", url, source, err)
+		}
+	}
+}
+
+func TestSerialConfigFromURL(t *testing.T) {
+	u, err := parseURL("serial:///dev/ttyUSB0?baud=115200&framing=7E2&flow=rtscts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := serialConfigFromURL(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SerialConfig{Device: "/dev/ttyUSB0", Baud: 115200, DataBits: 7, Parity: ParityEven, StopBits: 2, FlowControl: FlowRTSCTS}
+	if config != want {
+		t.Errorf("serialConfigFromURL() = %+v, want %+v", config, want)
+	}
+}
@@ -0,0 +1,200 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// FragmentMagic starts each datagram of a frame too big for one datagram, in
+// place of the frame sentinel.  It is followed by the fragment header and a
+// slice of the frame.
+const FragmentMagic uint32 = 0xDEADF4A6
+
+// fragmentHeader follows FragmentMagic in every fragment
+type fragmentHeader struct {
+	FrameID uint16 // counts up with each fragmented frame
+	Index   uint16 // which fragment of the frame this is, from 0
+	Count   uint16 // how many fragments the frame was split into
+}
+
+// fragmentOverhead the bytes each fragment spends on the magic and header
+var fragmentOverhead = 4 + binary.Size(fragmentHeader{})
+
+// DefaultMaxDatagram the most a datagram carries without IP fragmentation on Ethernet
+const DefaultMaxDatagram = 1472
+
+// UDP sends each frame as one datagram, splitting frames bigger than
+// MaxDatagram into fragments.  Lost datagrams are not resent, and frames that
+// can't be sent, e.g. because the receiver's port is closed or the send buffer
+// is full, are dropped.
+type UDP struct {
+	conn    *net.UDPConn
+	frameID uint16
+	buf     bytes.Buffer
+	dropped uint64
+
+	// MaxDatagram the largest datagram sent
+	MaxDatagram int
+}
+
+// DialUDP returns a UDP transport sending to addr, which may be a multicast group
+func DialUDP(addr string) (*UDP, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, err
+	}
+	return &UDP{conn: conn, MaxDatagram: DefaultMaxDatagram}, nil
+}
+
+// Write sends one frame, or drops it when it can't be sent; only a closed
+// socket is an error
+func (u *UDP) Write(p []byte) (int, error) {
+	if len(p) <= u.MaxDatagram {
+		if _, err := u.send(p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	size := u.MaxDatagram - fragmentOverhead
+	if size <= 0 {
+		return 0, fmt.Errorf("transport: datagrams of %d bytes can't hold a fragment", u.MaxDatagram)
+	}
+	count := (len(p) + size - 1) / size
+	if count > 0xFFFF {
+		return 0, fmt.Errorf("transport: %d byte frame needs too many fragments", len(p))
+	}
+	u.frameID++
+	for i := 0; i < count; i++ {
+		chunk := p[i*size:]
+		if len(chunk) > size {
+			chunk = chunk[:size]
+		}
+		u.buf.Reset()
+		binary.Write(&u.buf, binary.BigEndian, FragmentMagic)
+		binary.Write(&u.buf, binary.BigEndian, fragmentHeader{FrameID: u.frameID, Index: uint16(i), Count: uint16(count)})
+		u.buf.Write(chunk)
+		// the receiver drops a frame missing a fragment, so the rest aren't sent
+		if sent, err := u.send(u.buf.Bytes()); err != nil {
+			return i * size, err
+		} else if !sent {
+			break
+		}
+	}
+	return len(p), nil
+}
+
+// send writes one datagram, reporting whether it went; the frame it is part of
+// is dropped when it doesn't
+func (u *UDP) send(datagram []byte) (bool, error) {
+	if _, err := u.conn.Write(datagram); err != nil {
+		if errors.Is(err, net.ErrClosed) {
+			return false, err
+		}
+		// e.g. ECONNREFUSED after an ICMP port unreachable, or ENOBUFS
+		u.dropped++
+		return false, nil
+	}
+	return true, nil
+}
+
+// Dropped how many frames were not sent
+func (u *UDP) Dropped() uint64 {
+	return u.dropped
+}
+
+// Close closes the socket
+func (u *UDP) Close() error {
+	return u.conn.Close()
+}
+
+func (u *UDP) String() string {
+	return "udp " + u.conn.RemoteAddr().String()
+}
+
+// UDPSource receives frames sent by UDP transports, reassembling fragmented
+// frames.  A frame missing any fragment is dropped whole, which a frame.Decoder
+// sees as a gap in the sequence numbers.
+type UDPSource struct {
+	conn     *net.UDPConn
+	datagram []byte
+	pending  []byte // a whole frame waiting to be read
+
+	frameID   uint16
+	fragments [][]byte // of frameID, nil until received
+	received  int
+}
+
+// ListenUDP listens for datagrams on addr
+func ListenUDP(addr string) (*UDPSource, error) {
+	laddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	return &UDPSource{conn: conn, datagram: make([]byte, 0xFFFF)}, nil
+}
+
+// Addr the address being listened on, useful when addr gave port 0
+func (s *UDPSource) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Read reads the frames received so far as one stream
+func (s *UDPSource) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		n, err := s.conn.Read(s.datagram)
+		if err != nil {
+			return 0, err
+		}
+		s.receive(s.datagram[:n])
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// receive queues a whole frame, or holds a fragment until its frame is complete
+func (s *UDPSource) receive(datagram []byte) {
+	if len(datagram) < fragmentOverhead || binary.BigEndian.Uint32(datagram) != FragmentMagic {
+		s.pending = append([]byte(nil), datagram...)
+		return
+	}
+
+	header := fragmentHeader{}
+	binary.Read(bytes.NewReader(datagram[4:fragmentOverhead]), binary.BigEndian, &header)
+	if header.Count == 0 || header.Index >= header.Count {
+		return
+	}
+	if s.fragments == nil || header.FrameID != s.frameID || int(header.Count) != len(s.fragments) {
+		// only the latest frame is gathered; a fragment of a newer one gives up on the last
+		s.frameID = header.FrameID
+		s.fragments = make([][]byte, header.Count)
+		s.received = 0
+	}
+	if s.fragments[header.Index] != nil {
+		return
+	}
+	s.fragments[header.Index] = append([]byte(nil), datagram[fragmentOverhead:]...)
+	s.received++
+	if s.received < len(s.fragments) {
+		return
+	}
+	s.pending = bytes.Join(s.fragments, nil)
+	s.fragments = nil
+}
+
+// Close stops listening; a blocked Read returns an error
+func (s *UDPSource) Close() error {
+	return s.conn.Close()
+}
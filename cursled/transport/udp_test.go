@@ -0,0 +1,127 @@
+package transport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+)
+
+func bigFrame(numLEDs int) frame.Frame {
+	f := frame.Frame{}
+	for n := 0; n < numLEDs; n++ {
+		f.LEDs = append(f.LEDs, frame.LEDInfo{Row: uint16(n / 40), Column: uint16(n % 40), Red: uint8(n), Green: uint8(n * 7), Blue: uint8(n * 13), Brightness: 0xFF})
+	}
+	return f
+}
+
+func TestUDPFragments(t *testing.T) {
+	source, err := ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	sink, err := DialUDP(source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sink.MaxDatagram = 200
+
+	frames := []frame.Frame{bigFrame(2), bigFrame(800), bigFrame(5)}
+	enc := frame.NewEncoder(sink)
+	enc.Encoding = frame.EncodingDense
+	for _, f := range frames {
+		if err := enc.Encode(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	dec := frame.NewDecoder(source)
+	for i, want := range frames {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.LEDs) != len(want.LEDs) || got.LEDs[len(got.LEDs)-1] != want.LEDs[len(want.LEDs)-1] {
+			t.Errorf("frame %d has %d LEDs, want %d", i, len(got.LEDs), len(want.LEDs))
+		}
+	}
+}
+
+func TestUDPLostFragment(t *testing.T) {
+	var frames [][]byte
+	for _, f := range []frame.Frame{bigFrame(100), bigFrame(100)} {
+		var buf bytes.Buffer
+		frame.NewEncoder(&buf).Encode(f)
+		frames = append(frames, buf.Bytes())
+	}
+
+	source := &UDPSource{}
+	fragment := func(id uint16, p []byte, size int) [][]byte {
+		var datagrams [][]byte
+		count := (len(p) + size - 1) / size
+		for i := 0; i < count; i++ {
+			var buf bytes.Buffer
+			chunk := p[i*size:]
+			if len(chunk) > size {
+				chunk = chunk[:size]
+			}
+			buf.Write([]byte{0xDE, 0xAD, 0xF4, 0xA6, byte(id >> 8), byte(id), 0, byte(i), 0, byte(count)})
+			buf.Write(chunk)
+			datagrams = append(datagrams, buf.Bytes())
+		}
+		return datagrams
+	}
+
+	first := fragment(1, frames[0], 100)
+	for _, d := range first[:len(first)-1] {
+		source.receive(d)
+	}
+	if len(source.pending) != 0 {
+		t.Fatal("incomplete frame was passed on")
+	}
+	for _, d := range fragment(2, frames[1], 100) {
+		source.receive(d)
+	}
+	if !bytes.Equal(source.pending, frames[1]) {
+		t.Error("second frame was not reassembled after the first lost a fragment")
+	}
+	// the missing fragment of the first frame turning up late is ignored
+	source.pending = nil
+	source.receive(first[len(first)-1])
+	if len(source.pending) != 0 {
+		t.Error("late fragment completed a frame")
+	}
+}
+
+func TestUDPDropsUnsent(t *testing.T) {
+	// nothing listens on the port once the source is closed, so sends are refused
+	source, err := ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := source.Addr().String()
+	source.Close()
+	sink, err := DialUDP(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := frame.NewEncoder(sink)
+	for i := 0; i < 5; i++ {
+		if err := enc.Encode(bigFrame(2)); err != nil {
+			t.Fatalf("frame %d: %v, want it dropped", i, err)
+		}
+		time.Sleep(10 * time.Millisecond) // for the port unreachable to come back
+	}
+	if sink.Dropped() == 0 {
+		t.Error("no frames dropped sending to a closed port")
+	}
+
+	sink.Close()
+	if err := enc.Encode(bigFrame(2)); err == nil {
+		t.Error("Encode on a closed socket succeeded")
+	}
+}
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"bytes"
+
+	"github.com/aaronbush/go-stuff/cursled/frame"
+	"github.com/aaronbush/go-stuff/cursled/layout"
+)
+
+// wired rewrites the frames written to it so each LED is addressed by where it
+// sits along the strip rather than by its cell on the grid.  The LED at index i
+// of the layout is sent at row i/columns, column i%columns, the order a receiver
+// filling its strip row by row puts it in.
+type wired struct {
+	Transport
+	strip   map[layout.Cell][]int // the indexes along the strip showing each cell
+	columns int
+	buf     bytes.Buffer
+	encoder *frame.Encoder
+}
+
+// Wire lays out the frames written to t as display's layout says the LEDs are
+// wired; t is returned as it is when they are wired row by row.  Open wires the
+// sinks it opens itself, Wire is for those opened directly, like OpenSerial's.
+func Wire(t Transport, display Display) Transport {
+	l := display.Layout
+	if l == nil {
+		return t
+	}
+	columns := int(display.Header.Columns)
+	if columns == 0 {
+		columns = l.Len() // a single strip
+	}
+	w := &wired{Transport: t, strip: map[layout.Cell][]int{}, columns: columns}
+	identity := l.Len() == int(display.Header.Rows)*columns
+	for i := 0; i < l.Len(); i++ {
+		row, column, ok := l.Cell(i)
+		if !ok {
+			identity = false
+			continue
+		}
+		cell := layout.Cell{Row: row, Column: column}
+		w.strip[cell] = append(w.strip[cell], i)
+		identity = identity && row == i/columns && column == i%columns
+	}
+	if identity {
+		return t
+	}
+	w.encoder = frame.NewEncoder(&w.buf)
+	return w
+}
+
+// Write decodes the frame in p, moves its LEDs to their places along the strip
+// and writes it again with the same header and encoding
+func (w *wired) Write(p []byte) (int, error) {
+	f, err := frame.NewDecoder(bytes.NewReader(p)).Decode()
+	if err != nil {
+		return 0, err
+	}
+	leds := make([]frame.LEDInfo, 0, len(f.LEDs))
+	for _, led := range f.LEDs {
+		for _, i := range w.strip[layout.Cell{Row: int(led.Row), Column: int(led.Column)}] {
+			led.Row, led.Column = uint16(i/w.columns), uint16(i%w.columns)
+			leds = append(leds, led)
+		}
+	}
+	f.LEDs = leds
+
+	w.encoder.Version, w.encoder.Encoding, w.encoder.PixelFormat = f.Header.Version, f.Header.Encoding, f.Header.PixelFormat
+	w.encoder.WideCoordinates = f.Header.Flags&frame.FlagWideCoordinates != 0
+	w.buf.Reset()
+	if err := w.encoder.Encode(f); err != nil {
+		return 0, err
+	}
+	if _, err := w.Transport.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Reconnected passes on whether the transport underneath has reconnected
+func (w *wired) Reconnected() bool {
+	r, ok := w.Transport.(Reconnector)
+	return ok && r.Reconnected()
+}